@@ -17,6 +17,8 @@ limitations under the License.
 package affinity
 
 import (
+	"sort"
+
 	"github.com/laetho/metagraf/internal/pkg/params"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -45,7 +47,10 @@ func SoftPodAntiAffinity(app string, topologyKey string, weight int32) *corev1.A
 	var terms []corev1.WeightedPodAffinityTerm
 	var namespaces []string
 
-	namespaces = append(namespaces, params.NameSpace)
+	// Defaults to the namespace of the pod when empty.
+	if len(params.NameSpace) > 0 {
+		namespaces = append(namespaces, params.NameSpace)
+	}
 
 	terms = append(terms, corev1.WeightedPodAffinityTerm{
 		Weight: weight,
@@ -68,7 +73,10 @@ func HardPodAntiAffinity(app string, topologyKey string) *corev1.Affinity {
 	var terms []corev1.PodAffinityTerm
 	var namespaces []string
 
-	namespaces = append(namespaces, params.NameSpace)
+	// Defaults to the namespace of the pod when empty.
+	if len(params.NameSpace) > 0 {
+		namespaces = append(namespaces, params.NameSpace)
+	}
 
 	terms = append(terms, corev1.PodAffinityTerm{
 		LabelSelector: AntiAffinityLabelSelector("app", metav1.LabelSelectorOpIn, app),
@@ -84,3 +92,57 @@ func HardPodAntiAffinity(app string, topologyKey string) *corev1.Affinity {
 	}
 	return &aff
 }
+
+func nodeSelectorRequirements(labels map[string]string) []corev1.NodeSelectorRequirement {
+	var keys []string
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var reqs []corev1.NodeSelectorRequirement
+	for _, k := range keys {
+		reqs = append(reqs, corev1.NodeSelectorRequirement{
+			Key:      k,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{labels[k]},
+		})
+	}
+	return reqs
+}
+
+// Node affinity requiring nodes with all required labels and preferring
+// nodes with all preferred labels. Returns nil if both are empty.
+func NodeAffinity(required map[string]string, preferred map[string]string, weight int32) *corev1.NodeAffinity {
+	if len(required) == 0 && len(preferred) == 0 {
+		return nil
+	}
+
+	aff := corev1.NodeAffinity{}
+	if len(required) > 0 {
+		aff.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: nodeSelectorRequirements(required)},
+			},
+		}
+	}
+	if len(preferred) > 0 {
+		aff.PreferredDuringSchedulingIgnoredDuringExecution = []corev1.PreferredSchedulingTerm{
+			{
+				Weight:     weight,
+				Preference: corev1.NodeSelectorTerm{MatchExpressions: nodeSelectorRequirements(preferred)},
+			},
+		}
+	}
+	return &aff
+}
+
+// Spreads pods of app evenly across the domains of topologyKey.
+func TopologySpreadConstraint(app string, topologyKey string, maxSkew int32, when corev1.UnsatisfiableConstraintAction) corev1.TopologySpreadConstraint {
+	return corev1.TopologySpreadConstraint{
+		MaxSkew:           maxSkew,
+		TopologyKey:       topologyKey,
+		WhenUnsatisfiable: when,
+		LabelSelector:     AntiAffinityLabelSelector("app", metav1.LabelSelectorOpIn, app),
+	}
+}
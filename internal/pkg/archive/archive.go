@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package archive creates tar streams of local directories, used as
+// build context for binary builds.
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Name of the file listing paths to leave out of a build context.
+const IgnoreFile = ".mgignore"
+
+// Reads ignore patterns from file, one per line. Empty lines and lines
+// starting with # are skipped. A missing file is not an error.
+func ReadIgnoreFile(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// Returns true if the slash separated relative path rel matches any of the
+// patterns. A pattern matches the whole path, any path element or any
+// leading directory. Patterns use filepath.Match syntax.
+func Ignored(rel string, patterns []string) bool {
+	elements := strings.Split(rel, "/")
+	for _, p := range patterns {
+		p = strings.Trim(filepath.ToSlash(p), "/")
+		if len(p) == 0 {
+			continue
+		}
+		if ok, _ := filepath.Match(p, rel); ok {
+			return true
+		}
+		if strings.HasPrefix(rel, p+"/") {
+			return true
+		}
+		for _, e := range elements {
+			if ok, _ := filepath.Match(p, e); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Writes a tar stream of the contents of dir to w, leaving out
+// paths matching the ignore patterns.
+func Tar(dir string, w io.Writer, ignore []string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if Ignored(rel, ignore) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return addToTarWriter(tw, path, rel, info)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Same as Tar, but gzip compressed.
+func TarGz(dir string, w io.Writer, ignore []string) error {
+	gw := gzip.NewWriter(w)
+	err := Tar(dir, gw, ignore)
+	if err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func addToTarWriter(tw *tar.Writer, path string, name string, info os.FileInfo) error {
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		link, err = os.Readlink(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	patterns := []string{"target", "*.log", "docs/build/"}
+	tests := map[string]bool{
+		"src/main.go":          false,
+		"target":               true,
+		"target/app.jar":       true,
+		"sub/target/app.jar":   true,
+		"server.log":           true,
+		"docs/build/index.htm": true,
+		"docs/index.htm":       false,
+	}
+	for path, expected := range tests {
+		if actual := Ignored(path, patterns); actual != expected {
+			t.Errorf("Ignored(%v), expected: '%v', got: '%v'", path, expected, actual)
+		}
+	}
+}
+
+func TestTar(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":        "package main",
+		"target/app.jar": "jar",
+		"pkg/lib.go":     "package pkg",
+		IgnoreFile:       "# comment\n\ntarget\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ignore, err := ReadIgnoreFile(filepath.Join(dir, IgnoreFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Tar(dir, &buf, ignore); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+
+	expected := []string{IgnoreFile, "main.go", "pkg/", "pkg/lib.go"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Test failed, expected: '%v', got: '%v'", expected, names)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageinfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/mitchellh/go-homedir"
+	log "k8s.io/klog"
+)
+
+// Providers that can resolve an image reference to the digest of the
+// image it points to. Required for caching.
+type Resolver interface {
+	Provider
+	// Returns the digest, sha256:..., of the image imageref points to.
+	ResolveDigest(imageref string) (string, error)
+}
+
+// On disk cache of image metadata. Image configs are stored by image
+// digest and never expire, tag to digest resolutions expire after TTL.
+//
+//	<Dir>/configs/sha256/<hex>.json
+//	<Dir>/tags/<source>/<sha256 of reference>.json
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	// Ignore cached entries, but update the cache with fresh lookups.
+	Refresh bool
+}
+
+// A cached tag to digest resolution.
+type tagEntry struct {
+	Ref      string    `json:"ref"`
+	Digest   string    `json:"digest"`
+	Resolved time.Time `json:"resolved"`
+}
+
+// Returns a Cache configured from params, located in the mg config
+// directory unless params.ImageCacheDir is set.
+func NewCache() (Cache, error) {
+	dir := params.ImageCacheDir
+	if len(dir) == 0 {
+		config := params.ConfigDir
+		if len(config) == 0 {
+			home, err := homedir.Dir()
+			if err != nil {
+				return Cache{}, err
+			}
+			config = filepath.Join(home, ".config", "mg")
+		}
+		dir = filepath.Join(config, "cache", "images")
+	}
+	return Cache{
+		Dir:     dir,
+		TTL:     params.ImageCacheTTL,
+		Refresh: params.RefreshImageCache,
+	}, nil
+}
+
+// Wraps p so lookups go through the cache. Entries from different
+// sources are kept apart, since the same reference may resolve
+// differently through a registry and an ImageStream.
+func (c Cache) Wrap(source string, p Resolver) Provider {
+	return cachedProvider{cache: c, source: source, provider: p}
+}
+
+type cachedProvider struct {
+	cache    Cache
+	source   string
+	provider Resolver
+}
+
+func (p cachedProvider) ImageInfo(imageref string) (Info, error) {
+	digest, err := p.digest(imageref)
+	if err != nil {
+		return Info{}, err
+	}
+
+	file := p.cache.configFile(digest)
+	if !p.cache.Refresh {
+		info := Info{}
+		if readJSON(file, &info) {
+			log.V(2).Infof("Image config for %v (%v) read from cache", imageref, digest)
+			return info, nil
+		}
+	}
+
+	info, err := p.provider.ImageInfo(imageref)
+	if err != nil {
+		return Info{}, err
+	}
+	writeJSON(file, info)
+	return info, nil
+}
+
+func (p cachedProvider) ResolveDigest(imageref string) (string, error) {
+	return p.digest(imageref)
+}
+
+func (p cachedProvider) digest(imageref string) (string, error) {
+	file := p.cache.tagFile(p.source, imageref)
+	if !p.cache.Refresh {
+		entry := tagEntry{}
+		if readJSON(file, &entry) && time.Since(entry.Resolved) < p.cache.TTL {
+			return entry.Digest, nil
+		}
+	}
+
+	digest, err := p.provider.ResolveDigest(imageref)
+	if err != nil {
+		return "", err
+	}
+	writeJSON(file, tagEntry{Ref: imageref, Digest: digest, Resolved: time.Now()})
+	return digest, nil
+}
+
+func (c Cache) configFile(digest string) string {
+	return filepath.Join(c.Dir, "configs", strings.Replace(digest, ":", string(filepath.Separator), 1)+".json")
+}
+
+func (c Cache) tagFile(source string, imageref string) string {
+	sum := sha256.Sum256([]byte(imageref))
+	return filepath.Join(c.Dir, "tags", source, hex.EncodeToString(sum[:])+".json")
+}
+
+// Removes expired tag resolutions and image configs no longer referenced
+// by a tag resolution. Removes everything if all is true. Returns the
+// number of files removed.
+func (c Cache) Prune(all bool) (int, error) {
+	removed := 0
+	referenced := make(map[string]bool)
+
+	tags, err := filepath.Glob(filepath.Join(c.Dir, "tags", "*", "*.json"))
+	if err != nil {
+		return removed, err
+	}
+	for _, file := range tags {
+		entry := tagEntry{}
+		if !all && readJSON(file, &entry) && time.Since(entry.Resolved) < c.TTL {
+			referenced[c.configFile(entry.Digest)] = true
+			continue
+		}
+		err := os.Remove(file)
+		if err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	configs, err := filepath.Glob(filepath.Join(c.Dir, "configs", "*", "*.json"))
+	if err != nil {
+		return removed, err
+	}
+	for _, file := range configs {
+		if referenced[file] {
+			continue
+		}
+		err := os.Remove(file)
+		if err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Reads a cache entry. Missing or unreadable entries are cache misses.
+func readJSON(file string, v interface{}) bool {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(b, v) == nil
+}
+
+// Writes a cache entry through a temporary file, so concurrent mg
+// invocations never read a partial entry. Failing to write to the
+// cache is not fatal.
+func writeJSON(file string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+	}
+	var tmp *os.File
+	if err == nil {
+		tmp, err = ioutil.TempFile(filepath.Dir(file), ".tmp-")
+	}
+	if err == nil {
+		_, err = tmp.Write(b)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), file)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		log.Warningf("Unable to write image cache entry %v: %v", file, err)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageinfo
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Reads the image config from a local file, as written by
+// "crane config" or "skopeo inspect --config". The same config is
+// returned for every image reference.
+type FileProvider struct {
+	Path string
+}
+
+func (p FileProvider) ImageInfo(imageref string) (Info, error) {
+	if len(p.Path) == 0 {
+		return Info{}, errors.New("no image config file provided")
+	}
+
+	b, err := ioutil.ReadFile(p.Path)
+	if err != nil {
+		return Info{}, err
+	}
+
+	config := v1.ConfigFile{}
+	err = json.Unmarshal(b, &config)
+	if err != nil {
+		return Info{}, err
+	}
+	return Info(config.Config), nil
+}
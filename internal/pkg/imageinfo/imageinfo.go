@@ -1,66 +1,93 @@
 package imageinfo
 
 import (
-	"github.com/google/go-containerregistry/pkg/authn"
-	"github.com/google/go-containerregistry/pkg/name"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
 	v1 "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/laetho/metagraf/internal/pkg/helpers"
 	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/laetho/metagraf/pkg/metagraf"
-	"github.com/laetho/metagraf/pkg/mgver"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	log "k8s.io/klog"
+)
+
+// Sources of image metadata, selected with params.ImageInfoSource.
+const (
+	SourceRegistry    = "registry"
+	SourceImageStream = "imagestream"
+	SourceFile        = "file"
 )
 
+var errNoImage = errors.New("metaGraf specification references no image")
+
 // Type alias of Config type from go-containerregistry, for creating our own method sets.
 type Info v1.Config
 
-// Returns the oci or docker image config section based on images referenced
-// in the metaGraf specification.
-func MGImageInfo(mg *metagraf.MetaGraf) (Info, error) {
-	var imageref string
+// Provides the oci or docker image config of an image reference.
+type Provider interface {
+	ImageInfo(imageref string) (Info, error)
+}
+
+// Returns the Provider selected by params.ImageInfoSource. Registry and
+// ImageStream lookups go through the image cache unless params.NoImageCache.
+func NewProvider() (Provider, error) {
+	switch params.ImageInfoSource {
+	case SourceRegistry:
+		return cached(SourceRegistry, NewRegistryProvider()), nil
+	case SourceImageStream, "":
+		return cached(SourceImageStream, NewImageStreamProvider()), nil
+	case SourceFile:
+		return FileProvider{Path: params.ImageInfoFile}, nil
+	}
+	return nil, errors.New("unknown image info source: " + params.ImageInfoSource + ", use registry, imagestream or file")
+}
+
+// Returns the image reference to inspect for a metaGraf specification.
+// Prefers the base run image, then the build image, then the image.
+func MGImageRef(mg *metagraf.MetaGraf) string {
 	if len(mg.Spec.BaseRunImage) > 0 {
-		imageref = mg.Spec.BaseRunImage
+		return mg.Spec.BaseRunImage
 	} else if len(mg.Spec.BuildImage) > 0 {
-		imageref = mg.Spec.BuildImage
-	} else if len(mg.Spec.Image) > 0 {
-		imageref = mg.Spec.Image
+		return mg.Spec.BuildImage
+	}
+	return mg.Spec.Image
+}
+
+// Returns the oci or docker image config section based on images referenced
+// in the metaGraf specification, from the Provider selected by params.
+func MGImageInfo(mg *metagraf.MetaGraf) (Info, error) {
+	imageref := MGImageRef(mg)
+	if len(imageref) == 0 {
+		return Info{}, errNoImage
 	}
 
-	config, err := ImageInfo(imageref)
+	p, err := NewProvider()
 	if err != nil {
 		return Info{}, err
 	}
-	return config, nil
+	return p.ImageInfo(imageref)
 }
 
 // Returns the oci or docker image config from the imageref argument or an error.
 func ImageInfo(imageref string) (Info, error) {
+	return cached(SourceRegistry, NewRegistryProvider()).ImageInfo(imageref)
+}
 
-	ref, err := name.ParseReference(imageref)
-	if err != nil {
-		return Info{}, err
-	}
-
-	var options []remote.Option
-
-	options = append(options, remote.WithUserAgent("mg v"+mgver.GitTag))
-	if len(params.RegistryUser) > 0 {
-		options = append(options, remote.WithAuth(authn.FromConfig(authn.AuthConfig{
-			Username: params.RegistryUser,
-			Password: params.RegistryPassword,
-		})))
-	} else {
-		options = append(options, remote.WithAuth(authn.Anonymous))
+// Wraps p with the image cache, unless disabled or unavailable.
+func cached(source string, p Resolver) Provider {
+	if params.NoImageCache {
+		return p
 	}
-
-	img, err := remote.Image(ref, options...)
+	c, err := NewCache()
 	if err != nil {
-		return Info{}, err
+		log.Warningf("Image cache disabled: %v", err)
+		return p
 	}
-
-	config, _ := img.ConfigFile()
-	return Info(config.Config), nil
+	return c.Wrap(source, p)
 }
 
 // Turns the OCI or Docker image volume information into a
@@ -69,7 +96,7 @@ func (info Info) ImageVolumes(nameprefix string) []corev1.Volume {
 	var out []corev1.Volume
 
 	// Volumes & VolumeMounts from base image into podspec
-	for k := range info.Volumes {
+	for _, k := range sortedKeys(info.Volumes) {
 		// Volume Definitions
 		Volume := corev1.Volume{
 			Name: nameprefix + helpers.PathToIdentifier(k),
@@ -87,7 +114,7 @@ func (info Info) ImageVolumes(nameprefix string) []corev1.Volume {
 func (info Info) ImageVolumeMounts(nameprefix string) []corev1.VolumeMount {
 	var out []corev1.VolumeMount
 
-	for k := range info.Volumes {
+	for _, k := range sortedKeys(info.Volumes) {
 		VolumeMount := corev1.VolumeMount{
 			MountPath: k,
 			Name:      nameprefix + helpers.PathToIdentifier(k),
@@ -96,3 +123,64 @@ func (info Info) ImageVolumeMounts(nameprefix string) []corev1.VolumeMount {
 	}
 	return out
 }
+
+// Turns the exposed ports of the image into a slice of corev1.ContainerPort{}
+func (info Info) ContainerPorts() []corev1.ContainerPort {
+	var out []corev1.ContainerPort
+	for _, k := range sortedKeys(info.ExposedPorts) {
+		port, protocol := splitPort(k)
+		out = append(out, corev1.ContainerPort{
+			ContainerPort: port,
+			Protocol:      corev1.Protocol(strings.ToUpper(protocol)),
+		})
+	}
+	return out
+}
+
+// Turns the exposed ports of the image into a slice of corev1.ServicePort{}
+func (info Info) ServicePorts() []corev1.ServicePort {
+	var out []corev1.ServicePort
+	for _, k := range sortedKeys(info.ExposedPorts) {
+		port, protocol := splitPort(k)
+		out = append(out, corev1.ServicePort{
+			Name:       strconv.Itoa(int(port)) + "-" + protocol,
+			Port:       port,
+			Protocol:   corev1.Protocol(strings.ToUpper(protocol)),
+			TargetPort: intstr.FromInt(int(port)),
+		})
+	}
+	return out
+}
+
+// Returns the environment of the image, leaving out variables with
+// names containing any of the filter strings.
+func (info Info) EnvVars(filter []string) []corev1.EnvVar {
+	var out []corev1.EnvVar
+	for _, e := range info.Env {
+		es := strings.SplitN(e, "=", 2)
+		if len(es) != 2 || helpers.SliceInString(filter, strings.ToLower(es[0])) {
+			continue
+		}
+		out = append(out, corev1.EnvVar{Name: es[0], Value: es[1]})
+	}
+	return out
+}
+
+// Splits an exposed port like 8080/tcp into port and protocol.
+func splitPort(p string) (int32, string) {
+	ss := strings.SplitN(p, "/", 2)
+	port, _ := strconv.Atoi(ss[0])
+	if len(ss) == 1 {
+		return int32(port), "tcp"
+	}
+	return int32(port), ss[1]
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,208 @@
+package imageinfo
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/laetho/metagraf/internal/pkg/params"
+)
+
+var testConfig = v1.Config{
+	Env:          []string{"PATH=/usr/bin", "JAVA_OPTS=-Da=b"},
+	ExposedPorts: map[string]struct{}{"8443/tcp": {}, "8080/tcp": {}},
+	Volumes:      map[string]struct{}{"/data": {}},
+}
+
+// Starts an in memory registry holding an image with testConfig and
+// returns a reference to it.
+func testRegistryImage(t *testing.T) string {
+	s := httptest.NewServer(registry.New())
+	t.Cleanup(s.Close)
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imageref := u.Host + "/test/app:1.0.0"
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err = mutate.Config(img, testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference(imageref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = remote.Write(ref, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return imageref
+}
+
+func TestRegistryProvider(t *testing.T) {
+	imageref := testRegistryImage(t)
+
+	info, err := RegistryProvider{Keychain: authn.DefaultKeychain}.ImageInfo(imageref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ports := info.ContainerPorts()
+	if len(ports) != 2 || ports[0].ContainerPort != 8080 || ports[1].ContainerPort != 8443 {
+		t.Errorf("Expected ports 8080 and 8443, got %v", ports)
+	}
+	if sp := info.ServicePorts(); len(sp) != 2 || sp[0].Name != "8080-tcp" {
+		t.Errorf("Expected service port 8080-tcp, got %v", sp)
+	}
+	env := info.EnvVars([]string{"path"})
+	if len(env) != 1 || env[0].Name != "JAVA_OPTS" || env[0].Value != "-Da=b" {
+		t.Errorf("Expected JAVA_OPTS=-Da=b, got %v", env)
+	}
+	if mounts := info.ImageVolumeMounts("app"); len(mounts) != 1 || mounts[0].MountPath != "/data" {
+		t.Errorf("Expected a /data volume mount, got %v", mounts)
+	}
+
+	_, err = RegistryProvider{}.ImageInfo(filepath.Dir(imageref) + "/missing:latest")
+	if err == nil {
+		t.Error("Expected an error for a missing image")
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	err := ioutil.WriteFile(file, []byte(`{"config":{"Env":["A=1"],"ExposedPorts":{"8080/tcp":{}}}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := FileProvider{Path: file}.ImageInfo("any/image:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Env) != 1 || len(info.ExposedPorts) != 1 {
+		t.Errorf("Unexpected image config: %v", info)
+	}
+}
+
+func TestRegistryProviderDigest(t *testing.T) {
+	imageref := testRegistryImage(t)
+	p := RegistryProvider{Keychain: authn.DefaultKeychain}
+
+	pinned, err := p.Digest(imageref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := strings.TrimSuffix(imageref, ":1.0.0")
+	if !strings.HasPrefix(pinned, repo+"@sha256:") {
+		t.Errorf("Expected %v@sha256:..., got %v", repo, pinned)
+	}
+
+	again, err := p.Digest(pinned)
+	if err != nil || again != pinned {
+		t.Errorf("Expected digest reference %v to be kept, got %v, %v", pinned, again, err)
+	}
+
+	_, err = p.Digest(repo + ":2.0.0")
+	if err == nil || !strings.Contains(err.Error(), "tag 2.0.0 does not exist") {
+		t.Errorf("Expected missing tag error, got %v", err)
+	}
+}
+
+// Resolver counting lookups, for testing the cache.
+type countingResolver struct {
+	digest  string
+	lookups int
+}
+
+func (r *countingResolver) ImageInfo(imageref string) (Info, error) {
+	r.lookups++
+	return Info(testConfig), nil
+}
+
+func (r *countingResolver) ResolveDigest(imageref string) (string, error) {
+	r.lookups++
+	return r.digest, nil
+}
+
+func TestCache(t *testing.T) {
+	c := Cache{Dir: t.TempDir(), TTL: time.Hour}
+	r := &countingResolver{digest: "sha256:0123"}
+	p := c.Wrap(SourceRegistry, r)
+
+	for i := 0; i < 2; i++ {
+		info, err := p.ImageInfo("example.com/app:1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(info.Env, testConfig.Env) {
+			t.Errorf("Env = %v, want %v", info.Env, testConfig.Env)
+		}
+	}
+	if r.lookups != 2 {
+		t.Errorf("lookups = %v, want 2", r.lookups)
+	}
+
+	c.Refresh = true
+	_, err := c.Wrap(SourceRegistry, r).ImageInfo("example.com/app:1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.lookups != 4 {
+		t.Errorf("lookups after refresh = %v, want 4", r.lookups)
+	}
+
+	// An expired tag is resolved again, the config is still cached.
+	c = Cache{Dir: c.Dir, TTL: 0}
+	_, err = c.Wrap(SourceRegistry, r).ImageInfo("example.com/app:1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.lookups != 5 {
+		t.Errorf("lookups after expiry = %v, want 5", r.lookups)
+	}
+
+	removed, err := c.Prune(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("Prune removed %v, want 2", removed)
+	}
+}
+
+func TestNewCacheDir(t *testing.T) {
+	params.ConfigDir = "/etc/mg"
+	defer func() { params.ConfigDir = "" }()
+	c, err := NewCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Dir != filepath.Join("/etc/mg", "cache", "images") {
+		t.Errorf("Expected the cache in the mg config directory, got %v", c.Dir)
+	}
+
+	params.ImageCacheDir = "/tmp/images"
+	defer func() { params.ImageCacheDir = "" }()
+	c, _ = NewCache()
+	if c.Dir != "/tmp/images" {
+		t.Errorf("Expected the cache in --cache-dir, got %v", c.Dir)
+	}
+}
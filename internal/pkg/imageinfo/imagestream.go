@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/laetho/metagraf/internal/pkg/imageurl"
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	dockerv10 "github.com/openshift/api/image/docker10"
+	imagev1 "github.com/openshift/api/image/v1"
+	imagev1client "github.com/openshift/client-go/image/clientset/versioned/typed/image/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reads image configs from OpenShift ImageStreamTags. The namespace and
+// name of the ImageStreamTag are taken from the image reference.
+type ImageStreamProvider struct {
+	Client imagev1client.ImageV1Interface
+}
+
+func NewImageStreamProvider() ImageStreamProvider {
+	return ImageStreamProvider{Client: k8sclient.GetImageClient()}
+}
+
+func (p ImageStreamProvider) ImageInfo(imageref string) (Info, error) {
+	ist, err := p.imageStreamTag(imageref)
+	if err != nil {
+		return Info{}, err
+	}
+	if len(ist.Image.DockerImageMetadata.Raw) == 0 {
+		return Info{}, fmt.Errorf("ImageStreamTag %v/%v has no image metadata", ist.Namespace, ist.Name)
+	}
+
+	di := dockerv10.DockerImage{}
+	err = json.Unmarshal(ist.Image.DockerImageMetadata.Raw, &di)
+	if err != nil {
+		return Info{}, err
+	}
+	if di.Config == nil {
+		return Info{}, nil
+	}
+	return Info{
+		Env:          di.Config.Env,
+		ExposedPorts: di.Config.ExposedPorts,
+		Volumes:      di.Config.Volumes,
+	}, nil
+}
+
+// The name of the Image an ImageStreamTag points to is its digest.
+func (p ImageStreamProvider) ResolveDigest(imageref string) (string, error) {
+	ist, err := p.imageStreamTag(imageref)
+	if err != nil {
+		return "", err
+	}
+	return ist.Image.Name, nil
+}
+
+func (p ImageStreamProvider) imageStreamTag(imageref string) (*imagev1.ImageStreamTag, error) {
+	var imgurl imageurl.ImageURL
+	err := imgurl.Parse(imageref)
+	if err != nil {
+		return nil, err
+	}
+	return p.Client.ImageStreamTags(imgurl.Namespace).Get(context.TODO(), imgurl.Image+":"+imgurl.Tag, metav1.GetOptions{})
+}
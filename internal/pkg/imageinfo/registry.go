@@ -0,0 +1,169 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageinfo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/mgver"
+)
+
+// Reads image configs directly from the registry holding the image.
+type RegistryProvider struct {
+	// Resolves credentials for a registry.
+	Keychain authn.Keychain
+}
+
+// Returns a RegistryProvider using --reguser and --regpass if provided,
+// otherwise credentials from the docker config file.
+func NewRegistryProvider() RegistryProvider {
+	if len(params.RegistryUser) > 0 {
+		return RegistryProvider{Keychain: staticKeychain{authn.FromConfig(authn.AuthConfig{
+			Username: params.RegistryUser,
+			Password: params.RegistryPassword,
+		})}}
+	}
+	return RegistryProvider{Keychain: authn.DefaultKeychain}
+}
+
+func (p RegistryProvider) ImageInfo(imageref string) (Info, error) {
+	ref, err := name.ParseReference(imageref)
+	if err != nil {
+		return Info{}, err
+	}
+
+	img, err := remote.Image(ref, p.options()...)
+	if err != nil {
+		return Info{}, err
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		return Info{}, err
+	}
+	return Info(config.Config), nil
+}
+
+// Resolves imageref to a reference by digest, repository@sha256:...
+// Returns an error if the tag does not exist in the registry.
+func (p RegistryProvider) Digest(imageref string) (string, error) {
+	ref, err := name.ParseReference(imageref)
+	if err != nil {
+		return "", err
+	}
+	digest, err := p.ResolveDigest(imageref)
+	if err != nil {
+		return "", err
+	}
+	return ref.Context().Name() + "@" + digest, nil
+}
+
+// Returns the digest of the manifest imageref points to, without
+// downloading it.
+func (p RegistryProvider) ResolveDigest(imageref string) (string, error) {
+	ref, err := name.ParseReference(imageref)
+	if err != nil {
+		return "", err
+	}
+	if d, ok := ref.(name.Digest); ok {
+		return d.DigestStr(), nil
+	}
+
+	desc, err := remote.Head(ref, p.options()...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("tag %v does not exist in %v", ref.Identifier(), ref.Context().Name())
+		}
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
+// Copies the image or image index, with all platforms, src points to
+// to dst. Returns the digest of the copy after verifying it matches
+// the digest of src.
+func (p RegistryProvider) Copy(src string, dst string) (string, error) {
+	srcref, err := name.ParseReference(src)
+	if err != nil {
+		return "", err
+	}
+	dstref, err := name.ParseReference(dst)
+	if err != nil {
+		return "", err
+	}
+
+	desc, err := remote.Get(srcref, p.options()...)
+	if err != nil {
+		return "", err
+	}
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return "", err
+		}
+		err = remote.WriteIndex(dstref, idx, p.options()...)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		img, err := desc.Image()
+		if err != nil {
+			return "", err
+		}
+		err = remote.Write(dstref, img, p.options()...)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	digest, err := p.ResolveDigest(dst)
+	if err != nil {
+		return "", err
+	}
+	if digest != desc.Digest.String() {
+		return "", fmt.Errorf("digest of %v is %v, expected %v from %v", dst, digest, desc.Digest, src)
+	}
+	return digest, nil
+}
+
+func (p RegistryProvider) options() []remote.Option {
+	keychain := p.Keychain
+	if keychain == nil {
+		keychain = authn.DefaultKeychain
+	}
+	return []remote.Option{
+		remote.WithUserAgent("mg v" + mgver.GitTag),
+		remote.WithAuthFromKeychain(keychain),
+	}
+}
+
+// Keychain returning the same credentials for every registry.
+type staticKeychain struct {
+	auth authn.Authenticator
+}
+
+func (k staticKeychain) Resolve(authn.Resource) (authn.Authenticator, error) {
+	return k.auth, nil
+}
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
 )
 
 type ImageURL struct {
@@ -81,3 +83,18 @@ func (u *ImageURL) IsValid() bool {
 	}
 	return true
 }
+
+// Splits an image reference into the repository, as written, and the tag,
+// empty if the reference has none. A digest is ignored. Returns an error
+// if go-containerregistry can not parse the reference.
+func SplitTag(ref string) (string, string, error) {
+	ref = strings.Split(ref, "@")[0]
+	t, err := name.NewTag(ref, name.WithDefaultTag(""))
+	if err != nil {
+		return "", "", err
+	}
+	if len(t.TagStr()) == 0 {
+		return ref, "", nil
+	}
+	return strings.TrimSuffix(ref, ":"+t.TagStr()), t.TagStr(), nil
+}
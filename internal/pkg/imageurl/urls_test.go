@@ -32,3 +32,24 @@ func TestIsValid(t *testing.T) {
 		t.Errorf("Test failed, expected: '%v', got:  '%v'", expected, actual)
 	}
 }
+
+func TestSplitTag(t *testing.T) {
+	tests := []struct {
+		ref  string
+		repo string
+		tag  string
+	}{
+		{"registry:5000/ns/app", "registry:5000/ns/app", ""},
+		{"registry:5000/ns/app:1.0.0", "registry:5000/ns/app", "1.0.0"},
+		{"app:latest@sha256:0123", "app", "latest"},
+	}
+	for _, tt := range tests {
+		repo, tag, err := SplitTag(tt.ref)
+		if err != nil || repo != tt.repo || tag != tt.tag {
+			t.Errorf("SplitTag(%v) = %v, %v, %v, want %v, %v", tt.ref, repo, tag, err, tt.repo, tt.tag)
+		}
+	}
+	if _, _, err := SplitTag("Registry/App:1.0"); err == nil {
+		t.Errorf("Expected an error for an invalid reference")
+	}
+}
@@ -28,6 +28,7 @@ import (
 	buildv1client "github.com/openshift/client-go/build/clientset/versioned/typed/build/v1"
 	imagev1client "github.com/openshift/client-go/image/clientset/versioned/typed/image/v1"
 	routev1client "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -195,3 +196,16 @@ func GetArgoCDClient() *argocdv1alpha1client.ArgoprojV1alpha1Client {
 	}
 	return client
 }
+
+// Returns a dynamic client for resources we do not have typed clients for.
+func GetDynamicClient() dynamic.Interface {
+	if RestConfig == nil {
+		RestConfig = getRestConfig(getKubeConfig())
+	}
+
+	client, err := dynamic.NewForConfig(RestConfig)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
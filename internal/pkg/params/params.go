@@ -16,6 +16,8 @@ limitations under the License.
 
 package params
 
+import "time"
+
 var (
 
 	// Everything bool is a flag for indicating if we want to delete or operate on all resources.
@@ -44,6 +46,8 @@ var (
 	OutputImagestream string
 	// Override BuildSourceRef with somthing other than provided in specification.
 	SourceRef string
+	// Generate a BuildConfig per branch, named and tagged after the branch.
+	BranchBuildConfig bool
 
 	// Label and annotation namespacing filter
 	NameSpacingFilter string
@@ -74,6 +78,15 @@ var (
 	// Name of prometheus-operator instance that should discover the generated ServiceMonitor or PodMonitor resources.
 	ServiceMonitorOperatorName string = "prometheus"
 
+	// Set to true for generating PrometheusRule objects from the alerts section of a specification.
+	PrometheusRule bool = false
+	// Default range vector window for rate calculations in generated SLO alerting rules.
+	PrometheusRuleWindowDefault string = "5m"
+	// Default duration an alert condition must hold before firing.
+	PrometheusRuleForDefault string = "5m"
+	// Default severity label for generated alerting rules.
+	PrometheusRuleSeverityDefault string = "warning"
+
 	// Relative path to template file for use when creating a software component reference document.
 	RefTemplateFile       string = ""
 	RefTemplateOutputFile string = "REF.md"
@@ -83,6 +96,31 @@ var (
 	// RegistryPassword stores the explicitly defined password for a private registry. Usually passed to mg with --regpass.
 	RegistryPassword string
 
+	// Where to read image metadata from, registry, imagestream or file.
+	// Defaults to the ImageStreamTags of the OpenShift namespace.
+	ImageInfoSource string = "imagestream"
+	// Image config file used when ImageInfoSource is file.
+	ImageInfoFile string
+	// Rewrite image references to repository@sha256:... when rendering.
+	PinDigests bool
+	// Mount all Secrets and ConfigMaps in the /mg/... layout, ignoring
+	// mount paths, items, sub paths and modes from the specification.
+	LegacyMounts bool
+	// Base image family of the component, like rhel, debian or alpine. Selects
+	// the mount paths of global config types. Defaults to imagefamily in the
+	// mg config file.
+	ImageFamily string
+	// Directory of the mg config file, set from the --config flag.
+	ConfigDir string
+	// Directory for cached image metadata, defaults to cache/images in ConfigDir.
+	ImageCacheDir string
+	// How long a cached tag to digest resolution is trusted.
+	ImageCacheTTL time.Duration = time.Hour
+	// Bypass the image metadata cache. Usually passed to mg with --no-cache.
+	NoImageCache bool
+	// Ignore cached image metadata but update the cache. Usually passed to mg with --refresh.
+	RefreshImageCache bool
+
 	// Toggle for generating corev1.Affinity{} in Pod Templates in Deployment or DeploymentConfig.
 	WithAffinityRules bool
 	// Default value for WithAffinityRules if it's not set.
@@ -98,4 +136,28 @@ var (
 	// String to hold a container image name override
 	ImageName string
 
+	// Directory to write docker compose file and mounted configuration files to.
+	ComposeDir string
+
+	// Host and port of the OpenShift internal registry ImageStream tags point to.
+	// Overridden by the internalregistry config key.
+	InternalRegistryDefault string = "image-registry.openshift-image-registry.svc:5000"
+	// Periodically import spec.image from an external registry into the ImageStream.
+	ImageStreamScheduledImport bool
+	// Allow importing spec.image from a registry without valid TLS.
+	ImageStreamInsecureImport bool
+	// Reference policy for ImageStream tags, Source or Local.
+	ImageStreamReferencePolicy string = "Source"
+
+	// Hostname of the generated Route, overrides spec.route.host.
+	RouteHost string
+
+	// Format of generated Secrets, plain, sealed or external.
+	SecretFormat string = "plain"
+	// Public certificate of the sealed-secrets controller, for --secret-format sealed.
+	SealedSecretsCert string
+	// Name and kind of the store ExternalSecrets read from, for --secret-format external.
+	ExternalSecretStore     string
+	ExternalSecretStoreKind string = "SecretStore"
+
 )
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/generators/buildpacks"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	"github.com/laetho/metagraf/pkg/modules"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	log "k8s.io/klog"
+)
+
+func init() {
+	RootCmd.AddCommand(buildpacksCmd)
+	buildpacksCmd.AddCommand(buildpacksBuildCmd)
+
+	buildpacksBuildCmd.Flags().StringVarP(&buildpacks.BuildpacksJobOpts.Namespace, "namespace", "n", "", "Provide Kubernets namespace for Job creation.")
+	buildpacksBuildCmd.Flags().BoolVar(&Output, "output", false, "Output generated Job resource.")
+	buildpacksBuildCmd.Flags().BoolVar(&Dryrun, "dryrun", false, "Settings this to true will not create the Job in kubernetes.")
+	buildpacksBuildCmd.Flags().BoolVarP(&Watch, "watch", "w", false, "Watch the generated Buildpacks Job.")
+	buildpacksBuildCmd.Flags().BoolVarP(&Keep, "keep", "k", false, "Keep the completed or failed Buildpacks Job.")
+
+	buildpacksBuildCmd.Flags().StringVarP(&buildpacks.BuildpacksJobOpts.Registry, "registry", "r", viper.GetString("registry"), "Specify container registry host")
+	buildpacksBuildCmd.Flags().StringVarP(&buildpacks.BuildpacksJobOpts.ImageNS, "imagens", "i", "", "Image Namespace, defaults to --namespace")
+	buildpacksBuildCmd.Flags().StringVarP(&buildpacks.BuildpacksJobOpts.Tag, "tag", "t", buildpacks.BuildpacksJobOpts.Tag, "specify custom tag")
+	buildpacksBuildCmd.Flags().StringVar(&params.ImageName, "imagename", "", "Set image artifact name. Overrides imagename from metaGraf spec parsing behaviour.")
+	buildpacksBuildCmd.Flags().BoolVar(&params.DisableDeploymentImageAliasing, "disable-aliasing", false, "Only applies to .spec.image references. Push to .spec.image instead of the mg conventional image reference.")
+	buildpacksBuildCmd.Flags().StringVar(&buildpacks.BuildpacksJobOpts.GitImage, "git-image", buildpacks.BuildpacksJobOpts.GitImage, "Image used for cloning the repository.")
+	buildpacksBuildCmd.Flags().StringVar(&buildpacks.BuildpacksJobOpts.RegistrySecret, "registry-secret", "", "Name of docker-registry Secret with credentials for pushing the image.")
+	buildpacksBuildCmd.Flags().Int32Var(&buildpacks.BuildpacksJobOpts.BackoffLimit, "backoff-limit", buildpacks.BuildpacksJobOpts.BackoffLimit, "Number of retries before the Buildpacks Job is considered failed.")
+	buildpacksBuildCmd.Flags().Int64Var(&buildpacks.BuildpacksJobOpts.UserID, "uid", buildpacks.BuildpacksJobOpts.UserID, "User id of the builder image.")
+	buildpacksBuildCmd.Flags().Int64Var(&buildpacks.BuildpacksJobOpts.GroupID, "gid", buildpacks.BuildpacksJobOpts.GroupID, "Group id of the builder image.")
+	buildpacksBuildCmd.Flags().StringSliceVar(&CVars, "cvars", []string{}, "Slice of key=value pairs, seperated by ,")
+	buildpacksBuildCmd.Flags().StringVar(&params.PropertiesFile, "cvfile", "", "File with component configuration values. (key=value pairs)")
+}
+
+var buildpacksCmd = &cobra.Command{
+	Use:   "buildpacks",
+	Short: "buildpacks operations",
+	Long:  MGBanner + ` buildpacks `,
+}
+
+var buildpacksBuildCmd = &cobra.Command{
+	Use:   "build <metagraf>",
+	Short: "create a buildpacks build job from metaGraf specification",
+	Long:  MGBanner + `buildpacks build <metagraf.json>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireMetagraf(args)
+
+		mg := metagraf.Parse(args[0])
+
+		modules.Variables = GetCmdProperties(mg.GetProperties())
+		log.V(2).Info("Current MGProperties: ", modules.Variables)
+
+		generator := buildpacks.NewBuildpacksJobGenerator(mg, modules.Variables, buildpacks.BuildpacksJobOpts)
+		obj := generator.Generate("buildpacks-" + mg.Name("", ""))
+
+		if Output {
+			b, err := buildpacks.MarshalToYaml(obj)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(b))
+		}
+
+		if Dryrun {
+			return
+		}
+
+		err := generator.Create(obj)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !Watch {
+			return
+		}
+
+		stream, err := generator.LogsReader(obj)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s := *stream
+		defer s.Close()
+
+		for {
+			buf := make([]byte, 2000)
+			numBytes, err := s.Read(buf)
+			if numBytes > 0 {
+				fmt.Print(string(buf[:numBytes]))
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if !Keep {
+			err := generator.Delete(obj)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	},
+}
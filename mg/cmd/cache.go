@@ -0,0 +1,60 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/laetho/metagraf/internal/pkg/imageinfo"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/spf13/cobra"
+	log "k8s.io/klog"
+)
+
+var pruneAll bool
+
+func init() {
+	RootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	cacheCmd.PersistentFlags().StringVar(&params.ImageCacheDir, "cache-dir", "", "Image metadata cache directory (default is cache/images in the mg config directory)")
+	cachePruneCmd.Flags().DurationVar(&params.ImageCacheTTL, "cache-ttl", params.ImageCacheTTL, "Remove tag to digest resolutions older than this.")
+	cachePruneCmd.Flags().BoolVar(&pruneAll, "all", false, "Remove all cached image metadata.")
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "image metadata cache operations",
+	Long:  MGBanner + ` cache `,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "remove expired entries from the image metadata cache",
+	Long:  MGBanner + `cache prune`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := imageinfo.NewCache()
+		if err != nil {
+			log.Fatal(err)
+		}
+		removed, err := c.Prune(pruneAll)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Removed %v entries from %v\n", removed, c.Dir)
+	},
+}
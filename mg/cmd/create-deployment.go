@@ -30,7 +30,9 @@ func init() {
 	createDeploymentCmd.Flags().BoolVar(&params.WithAffinityRules, "with-affinity-rules", params.WithPodAffinityRulesDefault, "Enable generation of pod affinity or anti-affinity rules.")
 	createDeploymentCmd.Flags().StringVar(&params.PodAntiAffinityTopologyKey, "anti-affinity-topology-key", "", "Define which node label to use as a topologyKey (describing a datacenter, zone or a rack as an example)")
 	createDeploymentCmd.Flags().Int32Var(&params.PodAntiAffinityWeight, "pod-anti-affinity-weight", params.PodAntiAffinityWeightDefault, "Weight for WeightedPodAffinityTerm.")
+	createDeploymentCmd.Flags().BoolVar(&params.PinDigests, "pin-digests", false, "Resolve image references to digests through the registry. The original reference is kept in an annotation.")
 	createDeploymentCmd.Flags().BoolVar(&params.DownwardAPIEnvVars,"downward-api-envvars",false,"Enables generation of environment variables from Downward API. An opinionated selection.")
+	createDeploymentCmd.Flags().BoolVar(&params.DownwardAPIVolume, "downward-api-volume", false, "Mount a volume with the labels and annotations of the pod in /etc/podinfo.")
 }
 
 var createDeploymentCmd = &cobra.Command{
@@ -26,7 +26,12 @@ func init() {
 	createDeploymentConfigCmd.Flags().StringVarP(&Tag, "tag", "t", "latest", "specify custom tag")
 	createDeploymentConfigCmd.Flags().Int32Var(&params.Replicas, "replicas", params.DefaultReplicas, "Number of replicas.")
 	createDeploymentConfigCmd.Flags().BoolVar(&params.DisableDeploymentImageAliasing, "disable-aliasing", false, "Only applies to .spec.image references. Aliasing will use mg conventions for image references. Setting this to true will disable that behavior.")
+	createDeploymentConfigCmd.Flags().BoolVar(&params.PinDigests, "pin-digests", false, "Resolve image references to digests through the registry. The original reference is kept in an annotation.")
+	createDeploymentConfigCmd.Flags().BoolVar(&params.WithAffinityRules, "with-affinity-rules", params.WithPodAffinityRulesDefault, "Enable generation of pod affinity or anti-affinity rules.")
+	createDeploymentConfigCmd.Flags().StringVar(&params.PodAntiAffinityTopologyKey, "anti-affinity-topology-key", "", "Define which node label to use as a topologyKey (describing a datacenter, zone or a rack as an example)")
+	createDeploymentConfigCmd.Flags().Int32Var(&params.PodAntiAffinityWeight, "pod-anti-affinity-weight", params.PodAntiAffinityWeightDefault, "Weight for WeightedPodAffinityTerm.")
 	createDeploymentConfigCmd.Flags().BoolVar(&params.DownwardAPIEnvVars,"downward-api-envvars",false,"Enables generation of environment variables from Downward API. An opinionated selection.")
+	createDeploymentConfigCmd.Flags().BoolVar(&params.DownwardAPIVolume, "downward-api-volume", false, "Mount a volume with the labels and annotations of the pod in /etc/podinfo.")
 }
 
 var createDeploymentConfigCmd = &cobra.Command{
@@ -47,6 +52,12 @@ var createDeploymentConfigCmd = &cobra.Command{
 				os.Exit(1)
 			}
 		}
+		params.NameSpace = Namespace
+
+		if params.WithAffinityRules && len(params.PodAntiAffinityTopologyKey) == 0 {
+			log.Error("--anti-affinity-topology-key cannot be empty when --with-affinity-rules is active!")
+			os.Exit(1)
+		}
 
 		mg := metagraf.Parse(args[0])
 		FlagPassingHack()
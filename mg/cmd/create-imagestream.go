@@ -17,8 +17,10 @@ limitations under the License.
 package cmd
 
 import (
+	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/laetho/metagraf/pkg/metagraf"
 	"github.com/laetho/metagraf/pkg/modules"
+	imagev1 "github.com/openshift/api/image/v1"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	log "k8s.io/klog"
@@ -30,6 +32,9 @@ func init() {
 	createImageStreamCmd.Flags().StringVarP(&Namespace, "namespace", "n", "", "namespace to work on, if not supplied it will use current working namespace")
 	createImageStreamCmd.Flags().StringVar(&OName, "name", "", "Overrides name of application basename to generate imagestream for.")
 	createImageStreamCmd.Flags().StringSliceVar(&CVars, "cvars", []string{}, "Slice of key=value pairs, seperated by ,")
+	createImageStreamCmd.Flags().BoolVar(&params.ImageStreamScheduledImport, "scheduled-import", false, "Periodically import spec.image from an external registry.")
+	createImageStreamCmd.Flags().BoolVar(&params.ImageStreamInsecureImport, "insecure-import", false, "Allow importing spec.image from a registry without valid TLS.")
+	createImageStreamCmd.Flags().StringVar(&params.ImageStreamReferencePolicy, "reference-policy", params.ImageStreamReferencePolicy, "Reference policy for ImageStream tags, Source or Local.")
 
 }
 
@@ -52,6 +57,11 @@ var createImageStreamCmd = &cobra.Command{
 			}
 		}
 
+		if params.ImageStreamReferencePolicy != string(imagev1.SourceTagReferencePolicy) && params.ImageStreamReferencePolicy != string(imagev1.LocalTagReferencePolicy) {
+			log.Errorf("Invalid reference policy: %v, must be Source or Local", params.ImageStreamReferencePolicy)
+			os.Exit(1)
+		}
+
 		mg := metagraf.Parse(args[0])
 		FlagPassingHack()
 
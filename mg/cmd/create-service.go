@@ -19,6 +19,7 @@ func init() {
 	createServiceCmd.Flags().StringVar(&params.ServiceMonitorPath, "service-monitor-path", params.ServiceMonitorPathDefault, "Path to scrape metrics from.")
 	createServiceCmd.Flags().Int32Var(&params.ServiceMonitorPort, "service-monitor-port", params.ServiceMonitorPortDefault, "Set Service port to scrape by a ServiceMonitor.")
 	createServiceCmd.Flags().StringVar(&params.ServiceMonitorOperatorName, "service-monitor-operator-name", params.ServiceMonitorOperatorName, "Name of prometheus-operator instance to create ServiceMonitor for.")
+	createServiceCmd.Flags().BoolVar(&params.PrometheusRule, "prometheus-rule", false, "Set flag to also create a PrometheusRule resource from the alerts section. Requires a cluster with the prometheus-operator.")
 }
 
 var createServiceCmd = &cobra.Command{
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/laetho/metagraf/pkg/generators/tekton"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	log "k8s.io/klog"
+)
+
+// Flag for also starting the generated Pipeline.
+var TektonRun bool
+
+func init() {
+	createCmd.AddCommand(createTektonCmd)
+	createTektonCmd.Flags().StringVarP(&Namespace, "namespace", "n", "", "namespace to work on, if not supplied it will use current working namespace")
+	createTektonCmd.Flags().StringVar(&OName, "name", "", "Overrides name of application.")
+	createTektonCmd.Flags().StringVar(&tekton.PipelineOpts.BuildStrategy, "strategy", tekton.PipelineOpts.BuildStrategy, "Build strategy when the specification has a buildimage, s2i or buildpacks.")
+	createTektonCmd.Flags().StringVarP(&tekton.PipelineOpts.Registry, "registry", "r", "", "Specify container registry host")
+	createTektonCmd.Flags().StringVarP(&tekton.PipelineOpts.ImageNS, "imagens", "i", "", "Image Namespace, defaults to namespace")
+	createTektonCmd.Flags().StringVarP(&tekton.PipelineOpts.Tag, "tag", "t", tekton.PipelineOpts.Tag, "Tag for the built image")
+	createTektonCmd.Flags().StringVar(&tekton.PipelineOpts.SpecPath, "spec-path", "", "Path to the metaGraf specification in the repository. Defaults to the base name of <metagraf>.")
+	createTektonCmd.Flags().StringVar(&tekton.PipelineOpts.GitCloneTask, "git-clone-task", tekton.PipelineOpts.GitCloneTask, "Name of the Task used for cloning the repository.")
+	createTektonCmd.Flags().StringVar(&tekton.PipelineOpts.KanikoImage, "kaniko-image", tekton.PipelineOpts.KanikoImage, "Kaniko executor image.")
+	createTektonCmd.Flags().StringVar(&tekton.PipelineOpts.S2IImage, "s2i-image", tekton.PipelineOpts.S2IImage, "Image providing the s2i command.")
+	createTektonCmd.Flags().StringVar(&tekton.PipelineOpts.MGImage, "mg-image", tekton.PipelineOpts.MGImage, "Image providing mg for the deploy task.")
+	createTektonCmd.Flags().StringVar(&tekton.PipelineOpts.ServiceAccount, "service-account", "", "ServiceAccount for the PipelineRun.")
+	createTektonCmd.Flags().StringVar(&tekton.PipelineOpts.WorkspaceSize, "workspace-size", tekton.PipelineOpts.WorkspaceSize, "Size of the source workspace volume.")
+	createTektonCmd.Flags().BoolVar(&TektonRun, "run", false, "Also create a PipelineRun for the generated Pipeline.")
+}
+
+var createTektonCmd = &cobra.Command{
+	Use:   "tekton <metagraf>",
+	Short: "create Tekton Pipeline and PipelineRun from metaGraf file",
+	Long:  MGBanner + `create Tekton Pipeline`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			log.Info(StrActiveProject, viper.Get("namespace"))
+			log.Error(StrMissingMetaGraf)
+			os.Exit(1)
+		}
+
+		if len(Namespace) == 0 {
+			Namespace = viper.GetString("namespace")
+			if len(Namespace) == 0 {
+				log.Error(StrMissingNamespace)
+				os.Exit(1)
+			}
+		}
+
+		mg := metagraf.Parse(args[0])
+
+		tekton.PipelineOpts.Namespace = Namespace
+		if len(tekton.PipelineOpts.Registry) == 0 {
+			tekton.PipelineOpts.Registry = viper.GetString("registry")
+		}
+		if len(tekton.PipelineOpts.SpecPath) == 0 {
+			tekton.PipelineOpts.SpecPath = filepath.Base(args[0])
+		}
+
+		generator := tekton.NewPipelineGenerator(mg, tekton.PipelineOpts)
+		name := mg.Name(OName, Version)
+
+		pipeline, err := generator.Pipeline(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		run := generator.PipelineRun(name)
+
+		if Output {
+			outputTekton(pipeline)
+			if Format != "json" {
+				fmt.Println("---")
+			}
+			outputTekton(run)
+		}
+
+		if !Dryrun {
+			if err := generator.StorePipeline(pipeline); err != nil {
+				log.Fatal(err)
+			}
+			if TektonRun {
+				if err := generator.CreatePipelineRun(run); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+	},
+}
+
+func outputTekton(obj interface{}) {
+	var b []byte
+	var err error
+	if Format == "json" {
+		b, err = tekton.MarshalToJson(obj)
+	} else {
+		b, err = tekton.MarshalToYaml(obj)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+}
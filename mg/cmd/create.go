@@ -38,6 +38,16 @@ func init() {
 	createCmd.PersistentFlags().StringVar(&Version, "version", "", "Override version in metaGraf specification.")
 	createCmd.PersistentFlags().BoolVar(&Dryrun, "dryrun", false, "do not create objects, only output")
 	createCmd.PersistentFlags().StringSliceVar(&params.Labels, "labels", []string{}, "Provide extra labels as key=value pairs, seperated by ,")
+	createCmd.PersistentFlags().BoolVar(&params.LegacyMounts, "legacy-mounts", false, "Mount all Secrets and ConfigMaps in /mg/..., ignoring mount paths, items and modes in the specification. Non-global spec.secret entries are mounted by their name without the <name><version>- prefix.")
+	createCmd.PersistentFlags().StringVar(&params.ImageFamily, "image-family", "", "Base image family, like rhel, debian or alpine, selecting mount paths of global config types.")
+	createCmd.PersistentFlags().StringVar(&params.ImageInfoSource, "image-info", params.ImageInfoSource, "Where to read image metadata from, registry, imagestream or file.")
+	createCmd.PersistentFlags().StringVar(&params.ImageInfoFile, "image-info-file", "", "Image config file to use with --image-info file.")
+	createCmd.PersistentFlags().StringVar(&params.RegistryUser, "reguser", "", "Username for the registry holding the image. Defaults to credentials from the docker config file.")
+	createCmd.PersistentFlags().StringVar(&params.RegistryPassword, "regpass", "", "Password or token for --reguser.")
+	createCmd.PersistentFlags().StringVar(&params.ImageCacheDir, "cache-dir", "", "Image metadata cache directory (default is cache/images in the mg config directory)")
+	createCmd.PersistentFlags().BoolVar(&params.NoImageCache, "no-cache", false, "Do not read or write cached image metadata.")
+	createCmd.PersistentFlags().BoolVar(&params.RefreshImageCache, "refresh", false, "Ignore cached image metadata and refresh the cache.")
+	createCmd.PersistentFlags().DurationVar(&params.ImageCacheTTL, "cache-ttl", params.ImageCacheTTL, "How long cached tag to digest resolutions are trusted.")
 	createCmd.AddCommand(createConfigMapCmd)
 	createCmd.AddCommand(createDotCmd)
 	createCmd.AddCommand(createSecretCmd)
@@ -45,6 +55,10 @@ func init() {
 	createSecretCmd.Flags().StringVarP(&Namespace, "namespace", "n", "", "namespace to work on, if not supplied it will use current working namespace")
 	createSecretCmd.Flags().StringSliceVar(&CVars, "cvars", []string{}, "Slice of key=value pairs, seperated by ,")
 	createSecretCmd.Flags().BoolVarP(&CreateGlobals, "globals", "g", false, "Override default behavior and force creation of global secrets. Will not overwrite existing ones.")
+	createSecretCmd.Flags().StringVar(&params.SecretFormat, "secret-format", params.SecretFormat, "Format of generated Secrets, plain, sealed or external.")
+	createSecretCmd.Flags().StringVar(&params.SealedSecretsCert, "sealed-secrets-cert", "", "Public certificate of the sealed-secrets controller, used with --secret-format sealed.")
+	createSecretCmd.Flags().StringVar(&params.ExternalSecretStore, "secret-store", "", "Name of the store to read secrets from, used with --secret-format external.")
+	createSecretCmd.Flags().StringVar(&params.ExternalSecretStoreKind, "secret-store-kind", params.ExternalSecretStoreKind, "Kind of the store, SecretStore or ClusterSecretStore.")
 	createConfigMapCmd.Flags().StringVarP(&Namespace, "namespace", "n", "", "namespace to work on, if not supplied it will use current working namespace")
 	createConfigMapCmd.Flags().StringVar(&OName, "name", "", "Overrides name of application used to prefix configmaps.")
 	createConfigMapCmd.Flags().StringSliceVar(&CVars, "cvars", []string{}, "Slice of key=value pairs, seperated by ,")
@@ -53,6 +67,7 @@ func init() {
 	createRouteCmd.Flags().StringVar(&OName, "name", "", "Overrides name of application.")
 	createRouteCmd.Flags().StringSliceVar(&CVars, "cvars", []string{}, "Slice of key=value pairs, seperated by ,")
 	createRouteCmd.Flags().StringVarP(&Context, "context", "c", "/", "Application context root. (\"/<context>\")")
+	createRouteCmd.Flags().StringVar(&params.RouteHost, "host", "", "Hostname of the Route, overrides spec.route.host.")
 }
 
 var createCmd = &cobra.Command{
@@ -125,6 +140,23 @@ var createSecretCmd = &cobra.Command{
 				os.Exit(1)
 			}
 		}
+		switch params.SecretFormat {
+		case modules.SecretFormatPlain:
+		case modules.SecretFormatSealed:
+			if len(params.SealedSecretsCert) == 0 {
+				log.Error("--sealed-secrets-cert is required with --secret-format sealed")
+				os.Exit(1)
+			}
+		case modules.SecretFormatExternal:
+			if len(params.ExternalSecretStore) == 0 {
+				log.Error("--secret-store is required with --secret-format external")
+				os.Exit(1)
+			}
+		default:
+			log.Error("Unknown --secret-format ", params.SecretFormat, ", use plain, sealed or external")
+			os.Exit(1)
+		}
+
 		FlagPassingHack()
 		mg := metagraf.Parse(args[0])
 
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+	"github.com/laetho/metagraf/pkg/modules"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	log "k8s.io/klog"
+)
+
+// Branches that still exist, used when cleaning up per branch BuildConfigs.
+var Branches []string
+
+func init() {
+	deleteCmd.AddCommand(deleteBranchBuildConfigsCmd)
+	deleteBranchBuildConfigsCmd.Flags().StringVarP(&Namespace, "namespace", "n", "", "namespace to work on, if not supplied it will use current working namespace")
+	deleteBranchBuildConfigsCmd.Flags().StringVar(&OName, "name", "", "Overrides name of application.")
+	deleteBranchBuildConfigsCmd.Flags().StringSliceVar(&Branches, "branches", []string{}, "Branches that still exist, seperated by ,")
+	deleteBranchBuildConfigsCmd.Flags().BoolVar(&Dryrun, "dryrun", false, "only list BuildConfigs that would be deleted")
+}
+
+var deleteBranchBuildConfigsCmd = &cobra.Command{
+	Use:   "branch-buildconfigs <metagraf>",
+	Short: "delete per branch BuildConfigs for branches that no longer exist",
+	Long:  MGBanner + `delete branch-buildconfigs`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			log.Info(StrActiveProject, viper.Get("namespace"))
+			log.Error(StrMissingMetaGraf)
+			os.Exit(1)
+		}
+
+		if len(Namespace) == 0 {
+			Namespace = viper.GetString("namespace")
+			if len(Namespace) == 0 {
+				log.Error(StrMissingNamespace)
+				os.Exit(1)
+			}
+		}
+
+		mg := metagraf.Parse(args[0])
+		FlagPassingHack()
+
+		modules.DeleteStaleBranchBuildConfigs(&mg, Branches)
+	},
+}
@@ -17,15 +17,15 @@ limitations under the License.
 package cmd
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/laetho/metagraf/internal/pkg/archive"
 	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/laetho/metagraf/pkg/metagraf"
 	"github.com/laetho/metagraf/pkg/modules"
@@ -51,6 +51,16 @@ func init() {
 	devCmd.PersistentFlags().BoolVar(&Output, "output", false, "also output objects")
 	devCmd.PersistentFlags().BoolVar(&Dryrun, "dryrun", false, "do not create objects, only output")
 	devCmd.PersistentFlags().StringVarP(&Format, "format", "o", "json", "specify json or yaml, json id default")
+	devCmd.PersistentFlags().BoolVar(&params.LegacyMounts, "legacy-mounts", false, "Mount all Secrets and ConfigMaps in /mg/..., ignoring mount paths, items and modes in the specification. Non-global spec.secret entries are mounted by their name without the <name><version>- prefix.")
+	devCmd.PersistentFlags().StringVar(&params.ImageFamily, "image-family", "", "Base image family, like rhel, debian or alpine, selecting mount paths of global config types.")
+	devCmd.PersistentFlags().StringVar(&params.ImageInfoSource, "image-info", params.ImageInfoSource, "Where to read image metadata from, registry, imagestream or file.")
+	devCmd.PersistentFlags().StringVar(&params.ImageInfoFile, "image-info-file", "", "Image config file to use with --image-info file.")
+	devCmd.PersistentFlags().StringVar(&params.RegistryUser, "reguser", "", "Username for the registry holding the image. Defaults to credentials from the docker config file.")
+	devCmd.PersistentFlags().StringVar(&params.RegistryPassword, "regpass", "", "Password or token for --reguser.")
+	devCmd.PersistentFlags().StringVar(&params.ImageCacheDir, "cache-dir", "", "Image metadata cache directory (default is cache/images in the mg config directory)")
+	devCmd.PersistentFlags().BoolVar(&params.NoImageCache, "no-cache", false, "Do not read or write cached image metadata.")
+	devCmd.PersistentFlags().BoolVar(&params.RefreshImageCache, "refresh", false, "Ignore cached image metadata and refresh the cache.")
+	devCmd.PersistentFlags().DurationVar(&params.ImageCacheTTL, "cache-ttl", params.ImageCacheTTL, "How long cached tag to digest resolutions are trusted.")
 
 	devCmd.AddCommand(devCmdUp)
 	devCmdUp.Flags().StringVarP(&params.NameSpace, "namespace", "n", "", "namespace to work on, if not supplied it will use current active namespace.")
@@ -72,9 +82,10 @@ func init() {
 	devCmdDown.Flags().StringVar(&OName, "name", "", "Overrides name of application.")
 
 	devCmd.AddCommand(devCmdBuild)
-	devCmdBuild.Flags().StringVar(&params.SourceRef, "ref", "master", "Specify the git ref or branch ref to build.")
+	devCmdBuild.Flags().StringVar(&params.SourceRef, "ref", "", "Specify the git ref or branch ref to build. Defaults to .spec.branch.")
 	devCmdBuild.Flags().StringVarP(&params.NameSpace, "namespace", "n", "", "namespace to work on, if not supplied it will use current active namespace.")
 	devCmdBuild.Flags().BoolVar(&params.LocalBuild, "local", false, "Builds application from src in current (.) direcotry.")
+	devCmdBuild.Flags().StringSliceVar(&IgnoredPaths, "ignore-paths", []string{}, "List of paths to leave out of a --local build, seperated by \",\". Also read from .mgignore.")
 
 	devCmd.AddCommand(devCmdWatch)
 	devCmdWatch.Flags().StringVarP(&params.NameSpace, "namespace", "n", "", "namespace to work on, if not supplied it will use current active namespace.")
@@ -121,7 +132,8 @@ var devCmdWatch = &cobra.Command{
 					buildGenerate(&mg, params.NameSpace, true)
 					err := s2ibuild(bc, params.NameSpace, true)
 					if err != nil {
-						log.Fatalf("Unable to build: %v ", err)
+						log.Errorf("Unable to build: %v ", err)
+						break
 					}
 					devUp(args[0])
 				case Deploy:
@@ -203,9 +215,11 @@ func devDown(mgf string) {
 	mg := metagraf.Parse(mgf)
 	basename := modules.Name(&mg)
 
-	modules.DeleteRoute(basename)
+	modules.DeleteRoutes(&mg)
 	modules.DeleteService(basename)
 	modules.DeleteServiceMonitor(basename)
+	modules.DeletePodMonitor(basename)
+	modules.DeletePrometheusRule(basename)
 	modules.DeleteDeploymentConfig(basename)
 	modules.DeleteBuildConfig(basename)
 	modules.DeleteConfigMaps(&mg)
@@ -226,34 +240,37 @@ func buildGenerate(mg *metagraf.MetaGraf, ns string, local bool) {
 	modules.GenBuildConfig(mg)
 }
 
+// Starts a build from the named BuildConfig and follows it until it finishes.
+// A local build uploads the current directory, leaving out paths from
+// --ignore-paths and the .mgignore file.
 func s2ibuild(bc string, ns string, local bool) error {
-	path, err := exec.LookPath("oc")
+	modules.NameSpace = ns
+
+	if !local {
+		build, err := modules.StartBuild(bc)
+		if err != nil {
+			return err
+		}
+		return modules.FollowBuild(build.Name, os.Stdout)
+	}
+
+	ignore, err := archive.ReadIgnoreFile(archive.IgnoreFile)
 	if err != nil {
 		return err
 	}
+	ignore = append(ignore, IgnoredPaths...)
 
-	arg := []string{"start-build", bc, "-n", ns, "--follow"}
-	if local {
-		arg = append(arg, "--from-file=.")
-	}
-	c := exec.Command(path, arg...)
-	stdout, pipeerr := c.StdoutPipe()
-	if pipeerr != nil {
-		return pipeerr
-	}
-	c.Env = append(os.Environ())
-	cmderr := c.Start()
-	if cmderr != nil {
-		return cmderr
-	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(archive.Tar(".", pw, ignore))
+	}()
 
-	buf := bufio.NewReader(stdout)
-	line := []byte{}
-	for err == nil {
-		line, _, err = buf.ReadLine()
-		fmt.Println(string(line))
+	build, err := modules.StartBinaryBuild(bc, pr)
+	pr.Close()
+	if err != nil {
+		return err
 	}
-	return nil
+	return modules.FollowBuild(build.Name, os.Stdout)
 }
 
 // Watches for events in ./ using fsnotify and writes WatchEvent's to typed channel.
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	"github.com/laetho/metagraf/pkg/modules"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	log "k8s.io/klog"
+)
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.PersistentFlags().BoolVar(&Output, "output", false, "also output exported files")
+	exportCmd.PersistentFlags().BoolVar(&Dryrun, "dryrun", false, "do not write files, only output")
+	exportCmd.AddCommand(exportComposeCmd)
+	exportComposeCmd.Flags().StringVarP(&params.ComposeDir, "dir", "d", ".", "Directory to write the compose file and configuration files to.")
+	exportComposeCmd.Flags().StringSliceVar(&CVars, "cvars", []string{}, "Slice of key=value pairs, seperated by ,")
+	exportComposeCmd.Flags().StringVar(&params.PropertiesFile, "cvfile", "", "File with component configuration values. (key=value pairs)")
+	exportComposeCmd.Flags().BoolVar(&Defaults, "defaults", false, "Populate Environment variables with default values from metaGraf")
+	exportComposeCmd.Flags().StringVarP(&ImageNS, "imagens", "i", "", "Image Namespace, used when the specification has no image")
+	exportComposeCmd.Flags().StringVarP(&Registry, "registry", "r", viper.GetString("registry"), "Specify container registry host")
+	exportComposeCmd.Flags().StringVarP(&Tag, "tag", "t", "latest", "specify custom tag")
+	exportComposeCmd.Flags().BoolVar(&params.PinDigests, "pin-digests", false, "Resolve image references to digests through the registry.")
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export operations",
+	Long:  MGBanner + ` export `,
+}
+
+var exportComposeCmd = &cobra.Command{
+	Use:   "compose <metagraf|collection directory>",
+	Short: "export docker compose file from metaGraf file or collection",
+	Long:  MGBanner + `export compose`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			log.Error(StrMissingMetaGraf)
+			os.Exit(1)
+		}
+		FlagPassingHack()
+
+		mgs, err := parseSpecOrCollection(args[0])
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+
+		compose := modules.NewComposeFile()
+		for i := range mgs {
+			mgp := GetCmdProperties(mgs[i].GetProperties())
+			err := compose.AddService(&mgs[i], mgp, mgs)
+			if err != nil {
+				log.Error(err)
+				os.Exit(1)
+			}
+		}
+
+		err = compose.Write()
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// Parses a single metaGraf specification or all .json specifications in a
+// collection directory.
+func parseSpecOrCollection(path string) ([]metagraf.MetaGraf, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []metagraf.MetaGraf{metagraf.Parse(path)}, nil
+	}
+
+	var mgs []metagraf.MetaGraf
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		mgs = append(mgs, metagraf.Parse(file))
+	}
+	if len(mgs) == 0 {
+		return nil, fmt.Errorf("no metaGraf specifications found in: %v", strings.TrimSuffix(path, "/"))
+	}
+	return mgs, nil
+}
@@ -29,6 +29,7 @@ func init() {
 	injectCmd.AddCommand(injectAnnotationCmd)
 	injectCmd.AddCommand(injectVersionCmd)
 	injectCmd.AddCommand(injectSemVerCmd)
+	injectCmd.AddCommand(injectImageCmd)
 	//injectAnnotationsCmd.Flags().StringSliceVar(&CVars, "values", []string{}, "Slice of key=value pairs, seperated by ,")
 }
 
@@ -112,3 +113,25 @@ var injectSemVerCmd = &cobra.Command{
 
 	},
 }
+
+var injectImageCmd = &cobra.Command{
+	Use:   "image <metagraf> <image>",
+	Short: "Injects the image reference for the component.",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) < 2 {
+			log.Error("Missing arguments...")
+			os.Exit(1)
+		}
+
+		injectImage(args[0], args[1])
+	},
+}
+
+// Sets spec.image in the metaGraf specification stored in file.
+func injectImage(file string, image string) {
+	mg := metagraf.Parse(file)
+	mg.Spec.Image = image
+
+	metagraf.Store(file, &mg)
+}
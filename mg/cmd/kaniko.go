@@ -1,9 +1,7 @@
 package cmd
 
 import (
-	"archive/tar"
 	"bufio"
-	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -12,11 +10,14 @@ import (
 	"strings"
 
 	"github.com/ghodss/yaml"
+	"github.com/laetho/metagraf/internal/pkg/archive"
 	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/laetho/metagraf/pkg/generators/kaniko"
 	"github.com/laetho/metagraf/pkg/metagraf"
 	"github.com/laetho/metagraf/pkg/modules"
 	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	log "k8s.io/klog"
@@ -27,21 +28,29 @@ func init() {
 	kanikoCmd.AddCommand(kanikoBuildCmd)
 	kanikoCmd.AddCommand(kanikoCreateCmd)
 
-	kanikoBuildCmd.Flags().StringVarP(&kaniko.KanikoPodOpts.Namespace,"namespace", "n", "", "Provide Kubernets namespace for Pod creation." )
+	kanikoBuildCmd.Flags().StringVarP(&kaniko.KanikoJobOpts.Namespace,"namespace", "n", "", "Provide Kubernets namespace for Job creation." )
 	kanikoBuildCmd.Flags().BoolVar(&Output, "output", false, "Output generated Secret resource.")
 	kanikoBuildCmd.Flags().BoolVar(&Dryrun, "dryrun", false, "Settings this to true will not create Secret in kubernetes.")
-	kanikoBuildCmd.Flags().BoolVarP(&Watch, "watch", "w", false, "Watch the generated Kaniko Pod.")
-	kanikoBuildCmd.Flags().BoolVarP(&Keep, "keep","k",false,"Keep the completed or failed Kaniko Pod." )
-
-	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoPodOpts.DockerfileArg, "dockerfile", "Dockerfile", "Specify Kaniko --dockerfile argument")
-	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoPodOpts.ContextArg, "context","", "Specify Kaniko --context argument. Overrides Git ref from metaGraf specification.")
-	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoPodOpts.DestinationArg, "destination", "", "Specify Kaniko --destination argument. Registry reference.")
-
-	kanikoBuildCmd.Flags().BoolVar(&kaniko.KanikoPodOpts.Cache, "cache", false, "Specify Kaniko --cache to enable caching.")
-	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoPodOpts.CacheDir, "cache-dir", "", "Specify Kaniko --cache-dir to cache baseimages on local filesystem path.")
-
-	kanikoBuildCmd.Flags().BoolVar(&kaniko.KanikoPodOpts.SkipTLSVerify, "skip-tls-verify", false, "Set this flag to skip TLS verification when pushing to a registry.")
-	kanikoBuildCmd.Flags().BoolVar(&kaniko.KanikoPodOpts.SkipTLSVerifyPull, "skip-tls-verify-pull", false, "Set this flag to skip TLS verification when pulling from a registry.")
+	kanikoBuildCmd.Flags().BoolVarP(&Watch, "watch", "w", false, "Watch the generated Kaniko Job.")
+	kanikoBuildCmd.Flags().BoolVarP(&Keep, "keep","k",false,"Keep the completed or failed Kaniko Job." )
+
+	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoJobOpts.DockerfileArg, "dockerfile", "Dockerfile", "Specify Kaniko --dockerfile argument")
+	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoJobOpts.ContextArg, "context","", "Specify Kaniko --context argument. Overrides Git ref from metaGraf specification.")
+	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoJobOpts.DestinationArg, "destination", "", "Specify Kaniko --destination argument. Registry reference.")
+	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoJobOpts.TargetArg, "target", "", "Specify Kaniko --target argument. Stage to build in a multi stage Dockerfile.")
+	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoJobOpts.GitSHA, "git-sha", "", "Git commit the image is built from. Also pushed as a tag.")
+	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoJobOpts.RegistrySecret, "registry-secret", "", "Name of docker-registry Secret with credentials for pushing the image.")
+	kanikoBuildCmd.Flags().Int32Var(&kaniko.KanikoJobOpts.BackoffLimit, "backoff-limit", kaniko.KanikoJobOpts.BackoffLimit, "Number of retries before the Kaniko Job is considered failed.")
+	kanikoBuildCmd.Flags().StringSliceVar(&CVars, "cvars", []string{}, "Slice of key=value pairs, seperated by ,")
+	kanikoBuildCmd.Flags().StringVar(&params.PropertiesFile, "cvfile", "", "File with component configuration values. (key=value pairs)")
+
+	kanikoBuildCmd.Flags().BoolVar(&kaniko.KanikoJobOpts.Cache, "cache", false, "Specify Kaniko --cache to enable caching.")
+	kanikoBuildCmd.Flags().StringVar(&kaniko.KanikoJobOpts.CacheDir, "cache-dir", "", "Specify Kaniko --cache-dir to cache baseimages on local filesystem path.")
+
+	kanikoBuildCmd.Flags().BoolVar(&kaniko.KanikoJobOpts.SkipTLSVerify, "skip-tls-verify", false, "Set this flag to skip TLS verification when pushing to a registry.")
+	kanikoBuildCmd.Flags().BoolVar(&kaniko.KanikoJobOpts.SkipTLSVerifyPull, "skip-tls-verify-pull", false, "Set this flag to skip TLS verification when pulling from a registry.")
+	kanikoBuildCmd.Flags().BoolVar(&kaniko.KanikoJobOpts.Local, "local", false, "Build from the current directory. Streams it to the Kaniko Job over stdin.")
+	kanikoBuildCmd.Flags().StringSliceVar(&IgnoredPaths, "ignore-paths", []string{}, "List of paths to leave out of a --local build context, seperated by \",\". Also read from .mgignore.")
 
 	kanikoCreateCmd.AddCommand(kanikoCreateRegistryCredentialsCmd)
 	kanikoCreateRegistryCredentialsCmd.Flags().StringVarP(&Namespace, "namespace", "n", "", "Kubernetes namespace for generated Secret.")
@@ -65,7 +74,7 @@ var kanikoCreateCmd = &cobra.Command{
 
 var kanikoBuildCmd = &cobra.Command{
 	Use:   "build <metagraf>",
-	Short: "create a kaniko build pod from metaGraf specification",
+	Short: "create a kaniko build job from metaGraf specification",
 	Long:  MGBanner + `build kaniko <metagraf.json>`,
 	Run: func(cmd *cobra.Command, args []string) {
 		requireMetagraf(args)
@@ -75,8 +84,8 @@ var kanikoBuildCmd = &cobra.Command{
 		modules.Variables = GetCmdProperties(mg.GetProperties())
 		log.V(2).Info("Current MGProperties: ", modules.Variables)
 
-		generator := kaniko.NewKanikoPodGenerator(mg, metagraf.MGProperties{}, kaniko.KanikoPodOpts)
-		obj := generator.Generate("blah")
+		generator := kaniko.NewKanikoJobGenerator(mg, modules.Variables, kaniko.KanikoJobOpts)
+		obj := generator.Generate("kaniko-" + mg.Name("", ""))
 
 		if Output {
 			b, err := kaniko.MarshalToYaml(obj)
@@ -93,6 +102,14 @@ var kanikoBuildCmd = &cobra.Command{
 			}
 		}
 
+		local := generator.Options.Local && !Dryrun
+		if local {
+			err := uploadBuildContext(&generator, obj)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
 		if Watch {
 			stream, err := generator.LogsReader(obj)
 			if err != nil {
@@ -104,8 +121,8 @@ var kanikoBuildCmd = &cobra.Command{
 			for {
 				buf := make([]byte, 2000)
 				numBytes, err := s.Read(buf)
-				if numBytes == 0 {
-					continue
+				if numBytes > 0 {
+					fmt.Print(string(buf[:numBytes]))
 				}
 				if err == io.EOF {
 					break
@@ -113,8 +130,16 @@ var kanikoBuildCmd = &cobra.Command{
 				if err != nil {
 					log.Fatal(err)
 				}
-				message := string(buf[:numBytes])
-				fmt.Print(message)
+			}
+		}
+
+		if local {
+			digest, err := generator.Wait(obj)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, d := range generator.Destinations() {
+				fmt.Printf("Pushed image: %v@%v\n", d, digest)
 			}
 		}
 
@@ -202,31 +227,21 @@ func readInput() string {
 	return text
 }
 
-// Creates a .tar.gz archive from a slice of paths.
-func pathToTarGZ(tarball string, files []string) error {
-	file, err := os.Create(tarball)
+// Streams a tar.gz of the current directory to the Kaniko Job, leaving out
+// paths from --ignore-paths and the .mgignore file.
+func uploadBuildContext(generator *kaniko.KanikoJobGenerator, obj batchv1.Job) error {
+	ignore, err := archive.ReadIgnoreFile(archive.IgnoreFile)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	gzw := gzip.NewWriter(file)
-	defer gzw.Close()
-
-	tarw := tar.NewWriter(gzw)
-	defer tarw.Close()
-
-	for _, file := range files {
-		err := addToTarWriter(file, tarw)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
+	ignore = append(ignore, IgnoredPaths...)
 
-func addToTarWriter(filepath string, tarWriter *tar.Writer) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(archive.TarGz(".", pw, ignore))
+	}()
+	defer pr.Close()
 
-	return nil
+	log.Infof("Sending build context to Kaniko Job: %v", obj.Name)
+	return generator.Attach(obj, pr)
 }
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	"github.com/laetho/metagraf/pkg/modules"
+	"github.com/spf13/cobra"
+	log "k8s.io/klog"
+)
+
+var (
+	promoteFrom       string
+	promoteTo         string
+	promoteUpdateSpec bool
+)
+
+func init() {
+	RootCmd.AddCommand(promoteCmd)
+
+	promoteCmd.Flags().StringVar(&promoteFrom, "from", "", "Registry and namespace to promote the image from, registry/namespace.")
+	promoteCmd.Flags().StringVar(&promoteTo, "to", "", "Registry and namespace to promote the image to, registry/namespace.")
+	promoteCmd.Flags().BoolVar(&promoteUpdateSpec, "update-spec", false, "Set spec.image in the metaGraf specification to the promoted image.")
+	promoteCmd.Flags().BoolVar(&params.PinDigests, "pin-digests", false, "Use the digest of the promoted image with --update-spec.")
+	promoteCmd.Flags().StringVar(&Version, "version", "", "Override version in metaGraf specification.")
+	promoteCmd.Flags().StringVar(&OName, "name", "", "Overrides name of application.")
+	promoteCmd.Flags().StringVar(&params.RegistryUser, "reguser", "", "Username for the registries. Defaults to credentials from the docker config file.")
+	promoteCmd.Flags().StringVar(&params.RegistryPassword, "regpass", "", "Password or token for --reguser.")
+}
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote <metagraf>",
+	Short: "copy the image of a component from one registry to another",
+	Long:  MGBanner + `promote <metagraf.json> --from <registry/namespace> --to <registry/namespace>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireMetagraf(args)
+		if len(promoteFrom) == 0 || len(promoteTo) == 0 {
+			log.Fatal("Both --from and --to are required.")
+		}
+		FlagPassingHack()
+
+		mg := metagraf.Parse(args[0])
+
+		dst, digest, err := modules.Promote(&mg, promoteFrom, promoteTo)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Promoted image:", dst, digest)
+
+		if !promoteUpdateSpec {
+			return
+		}
+		if params.PinDigests {
+			dst = dst[:strings.LastIndex(dst, ":")] + "@" + digest
+		}
+		injectImage(args[0], dst)
+	},
+}
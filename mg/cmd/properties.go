@@ -208,6 +208,7 @@ func FlagPassingHack() {
 	modules.Dryrun = Dryrun
 	modules.NameSpace = Namespace
 	modules.Defaults = Defaults
+	modules.BaseEnvs = BaseEnvs
 	modules.Format = Format
 	modules.Suffix = Suffix
 	modules.Template = Template
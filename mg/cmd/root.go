@@ -19,7 +19,9 @@ package cmd
 import (
 	"flag"
 	"fmt"
+	"path/filepath"
 
+	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -68,6 +70,8 @@ var configkeys []string = []string{
 	"user",
 	"password",
 	"registry",
+	"internalregistry",
+	"imagefamily",
 }
 
 var RootCmd = &cobra.Command{
@@ -97,8 +101,10 @@ func initConfig() {
 	viper.SetConfigType("yaml")
 	if len(ConfigPath) > 0 {
 		viper.SetConfigFile(ConfigPath)
+		params.ConfigDir = filepath.Dir(ConfigPath)
 	} else {
 		ConfigPath = home+"/.config/mg/"
+		params.ConfigDir = ConfigPath
 		//fmt.Println(os.Stderr, "Using default config file: ~/.config/mg/config.yaml")
 		viper.AddConfigPath(ConfigPath)
 		viper.SetConfigName("config")
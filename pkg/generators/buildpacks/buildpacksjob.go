@@ -0,0 +1,418 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buildpacks generates Jobs building container images with the
+// Cloud Native Buildpacks lifecycle of a builder image.
+package buildpacks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	log "k8s.io/klog"
+)
+
+var podStartTimeout = 5 * time.Minute
+
+const (
+	errNoBuildImage  = "metaGraf specifies no buildimage, aborting build"
+	errNoRepository  = "metaGraf specifies no repository, aborting build"
+	errNoRegistry    = "no registry for the built image, use --registry or set registry in the mg config file"
+	errNoImageNS     = "no namespace for the built image, use --imagens or --namespace"
+	fetchContainer   = "fetch"
+	buildContainer   = "build"
+	workspaceVolume  = "workspace"
+	workspacePath    = "/workspace"
+	platformPath     = "/platform"
+	gitSecretVolume  = "git-secret"
+	gitSecretPath    = "/etc/git-secret"
+	dockerConfigVol  = "docker-config"
+	dockerConfigPath = "/docker-config"
+	jobNameLabel     = "job-name"
+)
+
+// Generator for a buildpacks build Job.
+type BuildpacksJobGenerator struct {
+	MetaGraf   metagraf.MetaGraf
+	Properties metagraf.MGProperties
+	Options    BuildpacksJobOptions
+	Resource   batchv1.Job
+}
+
+type BuildpacksJobOptions struct {
+	// Namespace for generated Job
+	Namespace string
+
+	// Registry, image namespace and tag of the built image.
+	Registry string
+	ImageNS  string
+	Tag      string
+
+	// Image with git used for cloning spec.repository.
+	GitImage string
+
+	// Number of retries before the Job is considered failed.
+	BackoffLimit int32
+
+	// Name of a docker-registry Secret with credentials for pushing
+	// the image.
+	RegistrySecret string
+
+	// User and group the lifecycle runs as. Must match the CNB_USER_ID
+	// and CNB_GROUP_ID of the builder image.
+	UserID  int64
+	GroupID int64
+}
+
+// Instance of BuildpacksJobOptions that can be used for propagating flags.
+var BuildpacksJobOpts BuildpacksJobOptions
+
+func init() {
+	BuildpacksJobOpts.Tag = "latest"
+	BuildpacksJobOpts.GitImage = "alpine/git:latest"
+	BuildpacksJobOpts.BackoffLimit = 2
+	BuildpacksJobOpts.UserID = 1000
+	BuildpacksJobOpts.GroupID = 1000
+}
+
+// Factory for creating a new BuildpacksJobGenerator
+func NewBuildpacksJobGenerator(mg metagraf.MetaGraf, prop metagraf.MGProperties, options BuildpacksJobOptions) BuildpacksJobGenerator {
+	g := BuildpacksJobGenerator{
+		MetaGraf:   mg,
+		Properties: prop,
+		Options:    options,
+	}
+
+	if len(g.MetaGraf.Spec.BuildImage) == 0 {
+		log.Fatal(errNoBuildImage)
+	}
+	if len(g.MetaGraf.Spec.Repository) == 0 {
+		log.Fatal(errNoRepository)
+	}
+	if len(g.Options.ImageNS) == 0 {
+		g.Options.ImageNS = g.Options.Namespace
+	}
+	if err := g.validateImage(); err != nil {
+		log.Fatal(err)
+	}
+	return g
+}
+
+// Returns the reference of the built image, the reference Deployments
+// and DeploymentConfigs pull. That is <registry>/<imagens>/<name>:<tag>,
+// or spec.image when params.DisableDeploymentImageAliasing.
+func (g *BuildpacksJobGenerator) Image() string {
+	if g.unaliased() {
+		return g.MetaGraf.Spec.Image
+	}
+	name := g.MetaGraf.Name("", "")
+	if len(params.ImageName) > 0 {
+		name = params.ImageName
+	}
+	return g.Options.Registry + "/" + g.Options.ImageNS + "/" + name + ":" + g.Options.Tag
+}
+
+func (g *BuildpacksJobGenerator) unaliased() bool {
+	return len(g.MetaGraf.Spec.Image) > 0 && params.DisableDeploymentImageAliasing
+}
+
+// Checks that the reference of the built image is complete and valid.
+func (g *BuildpacksJobGenerator) validateImage() error {
+	if !g.unaliased() {
+		if len(g.Options.Registry) == 0 {
+			return errors.New(errNoRegistry)
+		}
+		if len(g.Options.ImageNS) == 0 {
+			return errors.New(errNoImageNS)
+		}
+	}
+	if _, err := name.ParseReference(g.Image()); err != nil {
+		return fmt.Errorf("invalid image reference %v: %v", g.Image(), err)
+	}
+	return nil
+}
+
+// Generates a Job that clones the repository and runs the lifecycle
+// creator of the builder image. Defaults to buildpacks-<name> if
+// name is empty.
+func (g *BuildpacksJobGenerator) Generate(name string) batchv1.Job {
+	if len(name) == 0 {
+		name = "buildpacks-" + g.MetaGraf.Name("", "")
+	}
+	backoff := g.Options.BackoffLimit
+
+	g.Resource = batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: g.Options.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoff,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsUser:  &g.Options.UserID,
+						RunAsGroup: &g.Options.GroupID,
+						FSGroup:    &g.Options.GroupID,
+					},
+					InitContainers: []corev1.Container{g.fetchContainer()},
+					Containers:     []corev1.Container{g.buildContainer()},
+					Volumes:        g.volumes(),
+				},
+			},
+		},
+	}
+
+	return g.Resource
+}
+
+// Clones spec.repository into the workspace and writes the build
+// environment to the platform directory, where buildpacks read it.
+func (g *BuildpacksJobGenerator) fetchContainer() corev1.Container {
+	clone := "git clone --depth 1"
+	env := []corev1.EnvVar{
+		{Name: "HOME", Value: "/tmp"},
+		{Name: "REPOSITORY", Value: g.MetaGraf.Spec.Repository},
+	}
+	if len(g.MetaGraf.Spec.Branch) > 0 {
+		clone += " --branch \"$BRANCH\""
+		env = append(env, corev1.EnvVar{Name: "BRANCH", Value: g.MetaGraf.Spec.Branch})
+	}
+	script := []string{
+		"set -e",
+		clone + " \"$REPOSITORY\" " + workspacePath + "/app",
+		"mkdir -p " + platformPath + "/env",
+	}
+	for _, e := range g.buildEnv() {
+		script = append(script, "printf '%s' \"$"+e.Name+"\" > "+platformPath+"/env/"+e.Name)
+		env = append(env, e)
+	}
+
+	mounts := []corev1.VolumeMount{
+		{Name: workspaceVolume, MountPath: workspacePath},
+		{Name: workspaceVolume, MountPath: platformPath, SubPath: "platform"},
+	}
+	if len(g.MetaGraf.Spec.RepSecRef) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "GIT_SSH_COMMAND",
+			Value: "ssh -i " + gitSecretPath + "/ssh-privatekey -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null",
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: gitSecretVolume, MountPath: gitSecretPath, ReadOnly: true})
+	}
+
+	return corev1.Container{
+		Name:         fetchContainer,
+		Image:        g.Options.GitImage,
+		Command:      []string{"/bin/sh", "-c"},
+		Args:         []string{strings.Join(script, "\n")},
+		Env:          env,
+		VolumeMounts: mounts,
+	}
+}
+
+// Returns the build environment from the metaGraf specification with
+// values resolved from Properties. Falls back to the default value and
+// skips empty values.
+func (g *BuildpacksJobGenerator) buildEnv() []corev1.EnvVar {
+	var env []corev1.EnvVar
+	km := g.Properties.KeyMap()
+	for _, e := range g.MetaGraf.Spec.Environment.Build {
+		value := e.Default
+		if len(km[e.Name]) > 0 {
+			value = km[e.Name]
+		}
+		if len(value) == 0 {
+			continue
+		}
+		env = append(env, corev1.EnvVar{Name: e.Name, Value: value})
+	}
+	return env
+}
+
+func (g *BuildpacksJobGenerator) buildContainer() corev1.Container {
+	mounts := append(g.MetaGraf.BuildSecretsToVolumeMounts(),
+		corev1.VolumeMount{Name: workspaceVolume, MountPath: workspacePath},
+		corev1.VolumeMount{Name: workspaceVolume, MountPath: platformPath, SubPath: "platform"},
+	)
+	env := g.MetaGraf.KubernetesBuildVars()
+	if len(g.Options.RegistrySecret) > 0 {
+		mounts = append(mounts, corev1.VolumeMount{Name: dockerConfigVol, MountPath: dockerConfigPath, ReadOnly: true})
+		env = append(env, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: dockerConfigPath})
+	}
+
+	return corev1.Container{
+		Name:    buildContainer,
+		Image:   g.MetaGraf.Spec.BuildImage,
+		Command: []string{"/cnb/lifecycle/creator"},
+		Args: []string{
+			"-app=" + workspacePath + "/app",
+			"-platform=" + platformPath,
+			g.Image(),
+		},
+		Env:          env,
+		VolumeMounts: mounts,
+	}
+}
+
+func (g *BuildpacksJobGenerator) volumes() []corev1.Volume {
+	vols := append(g.MetaGraf.BuildSecretsToVolumes(), corev1.Volume{
+		Name:         workspaceVolume,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	if len(g.MetaGraf.Spec.RepSecRef) > 0 {
+		mode := int32(0400)
+		vols = append(vols, corev1.Volume{
+			Name: gitSecretVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  g.MetaGraf.Spec.RepSecRef,
+					DefaultMode: &mode,
+				},
+			},
+		})
+	}
+	if len(g.Options.RegistrySecret) > 0 {
+		vols = append(vols, corev1.Volume{
+			Name: dockerConfigVol,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: g.Options.RegistrySecret,
+					Items: []corev1.KeyToPath{
+						{Key: corev1.DockerConfigJsonKey, Path: "config.json"},
+					},
+				},
+			},
+		})
+	}
+	return vols
+}
+
+func (g *BuildpacksJobGenerator) Create(obj batchv1.Job) error {
+	client := k8sclient.GetKubernetesClient().BatchV1().Jobs(g.Options.Namespace)
+
+	result, err := client.Create(context.TODO(), &obj, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("Created Buildpacks Build Job: %v(%v)", result.Name, obj.Name)
+
+	return nil
+}
+
+// Deletes the Job and its Pods.
+func (g *BuildpacksJobGenerator) Delete(obj batchv1.Job) error {
+	client := k8sclient.GetKubernetesClient().BatchV1().Jobs(g.Options.Namespace)
+
+	propagation := metav1.DeletePropagationBackground
+	err := client.Delete(context.TODO(), obj.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil {
+		return err
+	}
+	log.Infof("Delete Buildpacks Build Job: %v", obj.Name)
+
+	return nil
+}
+
+// Return a reference to a io.ReadCloser following the log of the
+// lifecycle creator, or an error. Waits for the Pod to leave Pending,
+// which includes cloning the repository.
+func (g *BuildpacksJobGenerator) LogsReader(obj batchv1.Job) (*io.ReadCloser, error) {
+	client := k8sclient.GetCoreClient().Pods(g.Options.Namespace)
+
+	var pod *corev1.Pod
+	err := wait.PollImmediate(time.Second, podStartTimeout, func() (bool, error) {
+		pods, err := client.List(context.TODO(), metav1.ListOptions{LabelSelector: jobNameLabel + "=" + obj.Name})
+		if err != nil {
+			return false, err
+		}
+		pod = nil
+		for i := range pods.Items {
+			p := &pods.Items[i]
+			if pod == nil || pod.CreationTimestamp.Before(&p.CreationTimestamp) {
+				pod = p
+			}
+		}
+		if pod == nil {
+			return false, nil
+		}
+		if pod.Status.Phase == corev1.PodFailed {
+			return false, fmt.Errorf("pod %v failed before the build started", pod.Name)
+		}
+		return pod.Status.Phase != corev1.PodPending, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	podLogOptions := corev1.PodLogOptions{
+		Container: buildContainer,
+		Follow:    true,
+		TailLines: nil,
+	}
+
+	podLogReq := client.GetLogs(pod.Name, &podLogOptions)
+	stream, err := podLogReq.Stream(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	return &stream, nil
+}
+
+func (g *BuildpacksJobGenerator) ToYaml() ([]byte, error) {
+	b, err := MarshalToYaml(g.Resource)
+	return b, err
+}
+
+func (g *BuildpacksJobGenerator) ToJson() ([]byte, error) {
+	b, err := MarshalToJson(g.Resource)
+	return b, err
+}
+
+func MarshalToYaml(obj interface{}) ([]byte, error) {
+	y, err := yaml.Marshal(obj)
+	if err != nil {
+		return []byte{}, err
+	}
+	return y, nil
+}
+
+func MarshalToJson(obj interface{}) ([]byte, error) {
+	j, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return j, nil
+}
@@ -0,0 +1,130 @@
+package buildpacks
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    string
+		registry string
+		imagens  string
+		noalias  bool
+		want     string
+		valid    bool
+	}{
+		{"conventional", "", "registry:5000", "ns", false, "registry:5000/ns/appv1:latest", true},
+		{"aliased spec image", "registry/other/app:1.0.0", "registry:5000", "ns", false, "registry:5000/ns/appv1:latest", true},
+		{"unaliased spec image", "registry/other/app:1.0.0", "", "", true, "registry/other/app:1.0.0", true},
+		{"no registry", "", "", "ns", false, "/ns/appv1:latest", false},
+		{"no namespace", "", "registry", "", false, "registry//appv1:latest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mg := metagraf.MetaGraf{}
+			mg.Metadata.Name = "app"
+			mg.Spec.Version = "1.0.0"
+			mg.Spec.Image = tt.image
+			params.DisableDeploymentImageAliasing = tt.noalias
+			defer func() { params.DisableDeploymentImageAliasing = false }()
+			g := BuildpacksJobGenerator{
+				MetaGraf: mg,
+				Options:  BuildpacksJobOptions{Registry: tt.registry, ImageNS: tt.imagens, Tag: "latest"},
+			}
+			if got := g.Image(); got != tt.want {
+				t.Errorf("Image() = %v, want %v", got, tt.want)
+			}
+			if err := g.validateImage(); (err == nil) != tt.valid {
+				t.Errorf("validateImage() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.BuildImage = "paketobuildpacks/builder:base"
+	mg.Spec.Repository = "git@github.com:example/app.git"
+	mg.Spec.Branch = "develop"
+	mg.Spec.RepSecRef = "git-ssh"
+	mg.Spec.BuildSecret = []metagraf.Secret{{Name: "maven-settings", MountPath: "/platform/bindings/maven"}}
+	mg.Spec.Environment.Build = []metagraf.EnvironmentVar{
+		{Name: "BP_JVM_VERSION", Default: "11"},
+		{Name: "BP_MAVEN_BUILD_ARGUMENTS"},
+	}
+
+	g := NewBuildpacksJobGenerator(mg, metagraf.MGProperties{}, BuildpacksJobOptions{
+		Namespace:      "ns",
+		Registry:       "registry:5000",
+		Tag:            "latest",
+		GitImage:       "alpine/git:latest",
+		RegistrySecret: "push-secret",
+	})
+	job := g.Generate("")
+
+	if job.Name != "buildpacks-appv1" || job.Namespace != "ns" {
+		t.Errorf("Unexpected Job name %v in namespace %v", job.Name, job.Namespace)
+	}
+
+	pod := job.Spec.Template.Spec
+	build := pod.Containers[0]
+	if build.Image != "paketobuildpacks/builder:base" {
+		t.Errorf("Expected the builder image, got %v", build.Image)
+	}
+	if out := build.Args[len(build.Args)-1]; out != "registry:5000/ns/appv1:latest" {
+		t.Errorf("Expected the conventional output image, got %v", out)
+	}
+
+	fetch := pod.InitContainers[0]
+	env := map[string]string{}
+	for _, e := range fetch.Env {
+		env[e.Name] = e.Value
+	}
+	if env["REPOSITORY"] != mg.Spec.Repository || env["BRANCH"] != "develop" || env["BP_JVM_VERSION"] != "11" {
+		t.Errorf("Unexpected fetch env %v", fetch.Env)
+	}
+	if _, ok := env["BP_MAVEN_BUILD_ARGUMENTS"]; ok {
+		t.Errorf("Expected empty build env to be skipped, got %v", fetch.Env)
+	}
+
+	vols := map[string]corev1.Volume{}
+	for _, v := range pod.Volumes {
+		vols[v.Name] = v
+	}
+	if v, ok := vols["vol-maven-settings"]; !ok || v.Secret.SecretName != "maven-settings" {
+		t.Errorf("Expected a build secret volume, got %v", pod.Volumes)
+	}
+	if v, ok := vols[gitSecretVolume]; !ok || v.Secret.SecretName != "git-ssh" {
+		t.Errorf("Expected a git secret volume, got %v", pod.Volumes)
+	}
+	if v, ok := vols[dockerConfigVol]; !ok || v.Secret.SecretName != "push-secret" {
+		t.Errorf("Expected a docker config volume, got %v", pod.Volumes)
+	}
+
+	mounted := false
+	for _, m := range build.VolumeMounts {
+		if m.Name == "vol-maven-settings" && m.MountPath == "/platform/bindings/maven" {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Errorf("Expected the build secret mounted in the build container, got %v", build.VolumeMounts)
+	}
+	docker := false
+	for _, e := range build.Env {
+		if e.Name == "DOCKER_CONFIG" && e.Value == dockerConfigPath {
+			docker = true
+		}
+	}
+	if !docker {
+		t.Errorf("Expected DOCKER_CONFIG in the build env, got %v", build.Env)
+	}
+}
@@ -0,0 +1,539 @@
+package kaniko
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/ghodss/yaml"
+	"github.com/laetho/metagraf/internal/pkg/imageurl"
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	log "k8s.io/klog"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	podStartTimeout = 5 * time.Minute
+	buildTimeout    = 2 * time.Hour
+)
+
+const (
+	errNoDockerfile        = "metaGraf specifies no Dockerfile, aborting build"
+	terminationMessagePath = "/dev/termination-log"
+
+	containerName = "kaniko"
+
+	// Kaniko reads registry credentials from config.json in this directory.
+	dockerConfigVolume = "kaniko-docker-config"
+	dockerConfigPath   = "/kaniko/.docker"
+
+	// Label the Job controller sets on the Pods it creates.
+	jobNameLabel = "job-name"
+)
+
+type Generator interface {
+	Create(obj interface{}) error
+	Delete(obj interface{}) error
+}
+
+// Generator for the Application type
+type KanikoJobGenerator struct {
+	MetaGraf   metagraf.MetaGraf
+	Properties metagraf.MGProperties
+	Options    KanikoJobOptions
+	Resource   batchv1.Job
+}
+
+type KanikoJobOption func(options *KanikoJobOptions)
+
+type KanikoJobOptions struct {
+	// Namespace for generated Job
+	Namespace string
+
+	// Kaniko container image reference
+	Image string
+
+	// Number of retries before the Job is considered failed.
+	BackoffLimit int32
+
+	// Name of a docker-registry Secret with credentials for pushing
+	// the image. Mounted as /kaniko/.docker/config.json.
+	RegistrySecret string
+
+	// --destination Argument for Kaniko executor. Tags derived from
+	// the version in the metaGraf specification are added to it.
+	DestinationArg string
+
+	// Git commit the image is built from, pushed as an additional tag.
+	GitSHA string
+
+	// --dockerfile Argument for Kaniko executor.
+	// Path to Dockerfile in provided --context
+	DockerfileArg string
+
+	// --target Argument for Kaniko executor. Stage to build in a
+	// multi stage Dockerfile.
+	TargetArg string
+
+	// --context Argument for Kaniko executor.
+	// Se https://github.com/GoogleContainerTools/kaniko#kaniko-build-contexts for possible
+	// usage scenarios.
+	ContextArg string
+
+	// --cache Indicate if we want to use caching at all.
+	Cache bool
+
+	// Uses --cache-dir to set local directory as cache for base images. In our context
+	// this will be a Volume in the metaGraf specifiation that becomes a PersistantVolume.
+	CacheDir string
+
+	// Option for skipping cert verification on image push to a registry.
+	SkipTLSVerify bool
+
+	// Option for skipping cert verification on image pulls from a registry.
+	SkipTLSVerifyPull bool
+
+	StdIn     bool
+	StdInOnce bool
+
+	// Build from a local directory streamed to the Pod over stdin.
+	Local bool
+}
+
+// Instance of ApplicationOptions that can be used for propagating flags and addressable outside of pacage.
+var KanikoJobOpts KanikoJobOptions
+
+func init() {
+	KanikoJobOpts.Image = "gcr.io/kaniko-project/executor:debug"
+	KanikoJobOpts.DockerfileArg = "Dockerfile"
+	KanikoJobOpts.BackoffLimit = 2
+}
+
+// Creates a new Options based on defaults from Opts and runs
+// the functional Option methods against it from options.
+func NewOptions(options ...KanikoJobOption) KanikoJobOptions {
+	opts := KanikoJobOpts
+	o := &opts
+	for _, opt := range options {
+		opt(o)
+	}
+	return *o
+}
+
+// Factory for creating a new KanikoJobGenerator
+func NewKanikoJobGenerator(mg metagraf.MetaGraf, prop metagraf.MGProperties, options KanikoJobOptions) KanikoJobGenerator {
+	g := KanikoJobGenerator{
+		MetaGraf:   mg,
+		Properties: prop,
+		Options:    options,
+	}
+
+	if len(g.MetaGraf.Spec.Dockerfile) <= 0 {
+		log.Fatal(errNoDockerfile)
+	}
+	g.Options.DockerfileArg = mg.Spec.Dockerfile
+	if g.Options.Local {
+		g.Options.ContextArg = "tar://stdin"
+		g.Options.StdIn = true
+		g.Options.StdInOnce = true
+		// The build context can only be streamed once.
+		g.Options.BackoffLimit = 0
+	} else if len(g.Options.ContextArg) == 0 {
+		g.Options.ContextArg = mg.Spec.Repository
+	}
+
+	if len(g.MetaGraf.Spec.Image) > 0 {
+		g.Options.DestinationArg = g.MetaGraf.Spec.Image
+	}
+
+	return g
+}
+
+// Generates a Job running the Kaniko executor. Defaults to
+// kaniko-<name> if name is empty.
+func (g *KanikoJobGenerator) Generate(name string) batchv1.Job {
+	if len(name) == 0 {
+		name = "kaniko-" + g.MetaGraf.Name("", "")
+	}
+	backoff := g.Options.BackoffLimit
+
+	g.Resource = batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: g.Options.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoff,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    g.kanikoContainers(),
+					Volumes:       g.volumes(),
+				},
+			},
+		},
+	}
+
+	return g.Resource
+}
+
+// Returns the --destination arguments. The repository from DestinationArg
+// is tagged with the full version, the major version and the git sha.
+// An explicit tag in DestinationArg is kept.
+func (g *KanikoJobGenerator) Destinations() []string {
+	if len(g.Options.DestinationArg) == 0 {
+		return nil
+	}
+	repo, tag, err := imageurl.SplitTag(g.Options.DestinationArg)
+	if err != nil {
+		log.Fatalf("Invalid destination %v: %v", g.Options.DestinationArg, err)
+	}
+
+	var tags []string
+	if len(tag) > 0 {
+		tags = append(tags, tag)
+	}
+	if len(g.MetaGraf.Spec.Version) > 0 {
+		tags = append(tags, g.MetaGraf.Spec.Version)
+		sv, err := semver.Parse(g.MetaGraf.Spec.Version)
+		if err == nil {
+			tags = append(tags, strconv.FormatUint(sv.Major, 10))
+		}
+	}
+	if len(g.Options.GitSHA) > 0 {
+		tags = append(tags, g.Options.GitSHA)
+	}
+	if len(tags) == 0 {
+		return []string{repo}
+	}
+
+	var dests []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		dests = append(dests, repo+":"+t)
+	}
+	return dests
+}
+
+// Returns --build-arg values for the build environment in the
+// metaGraf specification, resolved from Properties. Falls back
+// to the default value and skips empty values.
+func (g *KanikoJobGenerator) buildArgs() []string {
+	var args []string
+	km := g.Properties.KeyMap()
+	for _, e := range g.MetaGraf.Spec.Environment.Build {
+		value := e.Default
+		if len(km[e.Name]) > 0 {
+			value = km[e.Name]
+		}
+		if len(value) == 0 {
+			continue
+		}
+		args = append(args, "--build-arg="+e.Name+"="+value)
+	}
+	sort.Strings(args)
+	return args
+}
+
+func (g *KanikoJobGenerator) podArgs() []string {
+	args := []string{}
+
+	if len(g.Options.DockerfileArg) > 0 {
+		args = append(args, "--dockerfile="+g.Options.DockerfileArg)
+	}
+	if len(g.Options.TargetArg) > 0 {
+		args = append(args, "--target="+g.Options.TargetArg)
+	}
+	for _, d := range g.Destinations() {
+		args = append(args, "--destination="+d)
+	}
+
+	if len(g.Options.ContextArg) > 0 {
+		args = append(args, "--context="+g.Options.ContextArg)
+	}
+	args = append(args, g.buildArgs()...)
+	if g.Options.SkipTLSVerifyPull {
+		args = append(args, "--skip-tls-verify-pull")
+	}
+	if g.Options.SkipTLSVerify {
+		args = append(args, "--skip-tls-verify")
+	}
+	if g.Options.Cache {
+		args = append(args, "--cache=true")
+	}
+	if g.Options.Cache && len(g.Options.CacheDir) > 0 {
+		args = append(args, "--cache-dir="+g.Options.CacheDir)
+		// Also cache RUN and copy layers.
+		args = append(args, "--cache-copy-layers")
+	}
+	// Makes the digest of the pushed image available in the Pod status.
+	args = append(args, "--digest-file="+terminationMessagePath)
+
+	return args
+}
+
+func (g *KanikoJobGenerator) kanikoContainers() []corev1.Container {
+	var containers []corev1.Container
+
+	mounts := append(g.MetaGraf.BuildSecretsToVolumeMounts(), g.MetaGraf.VolumesToVolumeMounts()...)
+	if len(g.Options.RegistrySecret) > 0 {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      dockerConfigVolume,
+			MountPath: dockerConfigPath,
+			ReadOnly:  true,
+		})
+	}
+
+	c := corev1.Container{
+		Name:                     containerName,
+		Image:                    g.Options.Image,
+		Args:                     g.podArgs(),
+		Env:                      g.MetaGraf.KubernetesBuildVars(),
+		VolumeMounts:             mounts,
+		TerminationMessagePath:   terminationMessagePath,
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		Stdin:                    g.Options.StdIn,
+		StdinOnce:                g.Options.StdInOnce,
+	}
+	return append(containers, c)
+}
+
+func (g *KanikoJobGenerator) volumes() []corev1.Volume {
+	vols := append(g.MetaGraf.BuildSecretsToVolumes(), g.MetaGraf.Volumes()...)
+	if len(g.Options.RegistrySecret) > 0 {
+		vols = append(vols, corev1.Volume{
+			Name: dockerConfigVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: g.Options.RegistrySecret,
+					Items: []corev1.KeyToPath{
+						{Key: corev1.DockerConfigJsonKey, Path: "config.json"},
+					},
+				},
+			},
+		})
+	}
+	return vols
+}
+
+func (g *KanikoJobGenerator) Create(obj batchv1.Job) error {
+	client := k8sclient.GetKubernetesClient().BatchV1().Jobs(g.Options.Namespace)
+
+	result, err := client.Create(context.TODO(), &obj, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("Created Kaniko Build Job: %v(%v)", result.Name, obj.Name)
+
+	return nil
+}
+
+// Deletes the Job and its Pods.
+func (g *KanikoJobGenerator) Delete(obj batchv1.Job) error {
+	client := k8sclient.GetKubernetesClient().BatchV1().Jobs(g.Options.Namespace)
+
+	propagation := metav1.DeletePropagationBackground
+	err := client.Delete(context.TODO(), obj.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil {
+		return err
+	}
+	log.Infof("Delete Kaniko Build Job: %v", obj.Name)
+
+	return nil
+}
+
+// Waits for the Job to create a Pod and returns the most recent one.
+func (g *KanikoJobGenerator) pod(obj batchv1.Job) (*corev1.Pod, error) {
+	client := k8sclient.GetCoreClient().Pods(g.Options.Namespace)
+
+	var pod *corev1.Pod
+	err := wait.PollImmediate(time.Second, podStartTimeout, func() (bool, error) {
+		pods, err := client.List(context.TODO(), metav1.ListOptions{LabelSelector: jobNameLabel + "=" + obj.Name})
+		if err != nil {
+			return false, err
+		}
+		for i := range pods.Items {
+			p := &pods.Items[i]
+			if pod == nil || pod.CreationTimestamp.Before(&p.CreationTimestamp) {
+				pod = p
+			}
+		}
+		return pod != nil, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("no pod created for job %v: %v", obj.Name, err)
+	}
+	return pod, nil
+}
+
+// Return a reference to a io.ReadCloser based on a Pod Log Request or an error.
+func (g *KanikoJobGenerator) LogsReader(obj batchv1.Job) (*io.ReadCloser, error) {
+	client := k8sclient.GetCoreClient().Pods(g.Options.Namespace)
+
+	pod, err := g.pod(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logs are available once the container has started.
+	err = wait.PollImmediate(time.Second, podStartTimeout, func() (bool, error) {
+		p, err := client.Get(context.TODO(), pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return p.Status.Phase != corev1.PodPending, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	podLogOptions := corev1.PodLogOptions{
+		Container: containerName,
+		Follow:    true,
+		TailLines: nil,
+	}
+
+	podLogReq := client.GetLogs(pod.Name, &podLogOptions)
+	stream, err := podLogReq.Stream(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	return &stream, nil
+}
+
+// Attaches to the stdin of the Kaniko container once it is running and
+// streams the build context from r. Returns when r is consumed.
+func (g *KanikoJobGenerator) Attach(obj batchv1.Job, r io.Reader) error {
+	client := k8sclient.GetCoreClient()
+
+	pod, err := g.pod(obj)
+	if err != nil {
+		return err
+	}
+
+	err = wait.PollImmediate(time.Second, podStartTimeout, func() (bool, error) {
+		p, err := client.Pods(g.Options.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		switch p.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return false, fmt.Errorf("pod %v exited before the build context was sent", pod.Name)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	req := client.RESTClient().Post().
+		Namespace(g.Options.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("attach").
+		VersionedParams(&corev1.PodAttachOptions{
+			Container: containerName,
+			Stdin:     true,
+		}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(k8sclient.RestConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	return exec.Stream(remotecommand.StreamOptions{Stdin: r})
+}
+
+// Waits for the Kaniko Job to finish. Returns the digest of the pushed
+// image, or an error if the build failed.
+func (g *KanikoJobGenerator) Wait(obj batchv1.Job) (string, error) {
+	client := k8sclient.GetKubernetesClient().BatchV1().Jobs(g.Options.Namespace)
+
+	var job *batchv1.Job
+	err := wait.PollImmediate(time.Second, buildTimeout, func() (bool, error) {
+		var err error
+		job, err = client.Get(context.TODO(), obj.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return jobFinished(job), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The last Pod holds the result, a digest or the reason it failed.
+	pod, err := g.pod(obj)
+	if err != nil {
+		return "", err
+	}
+	var message string
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Terminated != nil {
+			message = strings.TrimSpace(cs.State.Terminated.Message)
+		}
+	}
+
+	if job.Status.Succeeded == 0 {
+		return "", fmt.Errorf("kaniko build in job %v failed: %v", obj.Name, message)
+	}
+	return message, nil
+}
+
+func jobFinished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *KanikoJobGenerator) ToYaml() ([]byte, error) {
+	b, err := MarshalToYaml(g.Resource)
+	return b, err
+}
+
+func (g *KanikoJobGenerator) ToJson() ([]byte, error) {
+	b, err := MarshalToJson(g.Resource)
+	return b, err
+}
+
+func MarshalToYaml(obj interface{}) ([]byte, error) {
+	y, err := yaml.Marshal(obj)
+	if err != nil {
+		return []byte{}, err
+	}
+	return y, nil
+}
+
+func MarshalToJson(obj interface{}) ([]byte, error) {
+	j, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return j, nil
+}
@@ -0,0 +1,38 @@
+package kaniko
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+)
+
+func TestDestinations(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		version     string
+		sha         string
+		want        []string
+	}{
+		{"semver", "registry:5000/ns/app", "1.2.3", "", []string{"registry:5000/ns/app:1.2.3", "registry:5000/ns/app:1"}},
+		{"explicit tag", "registry/ns/app:latest", "2.0.0", "abc123", []string{"registry/ns/app:latest", "registry/ns/app:2.0.0", "registry/ns/app:2", "registry/ns/app:abc123"}},
+		{"no version", "registry/ns/app", "", "", []string{"registry/ns/app"}},
+		{"no destination", "", "1.0.0", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mg := metagraf.MetaGraf{}
+			mg.Spec.Version = tt.version
+			g := KanikoJobGenerator{
+				MetaGraf: mg,
+				Options:  KanikoJobOptions{DestinationArg: tt.destination, GitSHA: tt.sha},
+			}
+			got := g.Destinations()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Destinations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
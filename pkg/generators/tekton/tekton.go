@@ -0,0 +1,500 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tekton
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/ghodss/yaml"
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	log "k8s.io/klog"
+)
+
+const (
+	errNoBuild            = "metaGraf specifies neither dockerfile nor buildimage, unable to generate build task"
+	errUnknownStrategy    = "unknown build strategy, use s2i or buildpacks"
+	StrategyS2I           = "s2i"
+	StrategyBuildpacks    = "buildpacks"
+	sourceWorkspace       = "source"
+	credentialsWorkspace  = "git-credentials"
+	fetchTaskName         = "fetch-source"
+	buildTaskName         = "build"
+	deployTaskName        = "deploy"
+	generatedSourceVolume = "gen-source"
+	generatedSourcePath   = "/gen-source"
+	dockerConfigPath      = "/tekton/home/.docker/"
+)
+
+var (
+	pipelineResource    = schema.GroupVersionResource{Group: "tekton.dev", Version: "v1beta1", Resource: "pipelines"}
+	pipelineRunResource = schema.GroupVersionResource{Group: "tekton.dev", Version: "v1beta1", Resource: "pipelineruns"}
+)
+
+// Generator for Tekton Pipeline and PipelineRun resources.
+type PipelineGenerator struct {
+	MetaGraf metagraf.MetaGraf
+	Options  PipelineOptions
+}
+
+type PipelineOptions struct {
+	// Namespace for the generated resources and the deployed component.
+	Namespace string
+
+	// Strategy for building from a spec.buildimage, s2i or buildpacks.
+	BuildStrategy string
+
+	// Registry, image namespace and tag for the built image. Passed on to
+	// mg in the deploy task so the Deployment references the same image.
+	Registry string
+	ImageNS  string
+	Tag      string
+
+	// Path to the metaGraf specification relative to the repository root.
+	SpecPath string
+
+	// Name of the Task used for cloning the repository. Expects the
+	// git-clone Task from the Tekton catalog or a compatible one.
+	GitCloneTask string
+
+	// Container images used by the build and deploy steps.
+	KanikoImage string
+	S2IImage    string
+	MGImage     string
+
+	// ServiceAccount for the PipelineRun, should hold registry credentials.
+	ServiceAccount string
+
+	// Size of the volume claimed for the source workspace.
+	WorkspaceSize string
+}
+
+// Instance of PipelineOptions that can be used for propagating flags.
+var PipelineOpts PipelineOptions
+
+func init() {
+	PipelineOpts.BuildStrategy = StrategyS2I
+	PipelineOpts.Tag = "latest"
+	PipelineOpts.GitCloneTask = "git-clone"
+	PipelineOpts.KanikoImage = "gcr.io/kaniko-project/executor:latest"
+	PipelineOpts.S2IImage = "quay.io/openshift-pipeline/s2i:nightly"
+	PipelineOpts.MGImage = "laetho/mg:latest"
+	PipelineOpts.WorkspaceSize = "1Gi"
+}
+
+// Factory for creating a new PipelineGenerator
+func NewPipelineGenerator(mg metagraf.MetaGraf, options PipelineOptions) PipelineGenerator {
+	g := PipelineGenerator{
+		MetaGraf: mg,
+		Options:  options,
+	}
+	if len(g.Options.ImageNS) == 0 {
+		g.Options.ImageNS = g.Options.Namespace
+	}
+	return g
+}
+
+// Generates a Pipeline that clones the repository, builds and pushes a
+// container image and deploys the component with mg.
+func (g *PipelineGenerator) Pipeline(name string) (Pipeline, error) {
+	build, err := g.buildTask(name)
+	if err != nil {
+		return Pipeline{}, err
+	}
+
+	workspaces := []WorkspaceDeclaration{
+		{Name: sourceWorkspace},
+	}
+	if len(g.MetaGraf.Spec.RepSecRef) > 0 {
+		workspaces = append(workspaces, WorkspaceDeclaration{Name: credentialsWorkspace})
+	}
+	for _, s := range g.MetaGraf.Spec.BuildSecret {
+		workspaces = append(workspaces, WorkspaceDeclaration{Name: s.VolumeName()})
+	}
+
+	obj := Pipeline{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Pipeline",
+			APIVersion: APIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: g.Options.Namespace,
+			Labels:    g.labels(name),
+		},
+		Spec: PipelineSpec{
+			Description: g.MetaGraf.Spec.Description,
+			Params:      g.pipelineParams(),
+			Workspaces:  workspaces,
+			Tasks: []PipelineTask{
+				g.fetchTask(),
+				build,
+				g.deployTask(),
+			},
+		},
+	}
+	return obj, nil
+}
+
+// Generates a PipelineRun template for the Pipeline named name. Each
+// run gets a fresh volume for the source workspace, the repository secret
+// and build secrets are bound as secret workspaces.
+func (g *PipelineGenerator) PipelineRun(name string) PipelineRun {
+	workspaces := []WorkspaceBinding{
+		{
+			Name: sourceWorkspace,
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaim{
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse(g.Options.WorkspaceSize),
+						},
+					},
+				},
+			},
+		},
+	}
+	if len(g.MetaGraf.Spec.RepSecRef) > 0 {
+		workspaces = append(workspaces, WorkspaceBinding{
+			Name:   credentialsWorkspace,
+			Secret: &corev1.SecretVolumeSource{SecretName: g.MetaGraf.Spec.RepSecRef},
+		})
+	}
+	for _, s := range g.MetaGraf.Spec.BuildSecret {
+		workspaces = append(workspaces, WorkspaceBinding{
+			Name: s.VolumeName(),
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: s.Name,
+				Items:      s.Items,
+			},
+		})
+	}
+
+	return PipelineRun{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PipelineRun",
+			APIVersion: APIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + "-",
+			Namespace:    g.Options.Namespace,
+			Labels:       g.labels(name),
+		},
+		Spec: PipelineRunSpec{
+			PipelineRef: &PipelineRef{Name: name},
+			Params: []Param{
+				{Name: "git-url", Value: g.MetaGraf.Spec.Repository},
+				{Name: "git-revision", Value: g.revision()},
+			},
+			ServiceAccountName: g.Options.ServiceAccount,
+			Workspaces:         workspaces,
+		},
+	}
+}
+
+func (g *PipelineGenerator) labels(name string) map[string]string {
+	l := make(map[string]string)
+	for k, v := range g.MetaGraf.Metadata.Labels {
+		l[k] = v
+	}
+	l["app"] = name
+	return l
+}
+
+func (g *PipelineGenerator) revision() string {
+	if len(g.MetaGraf.Spec.Branch) > 0 {
+		return g.MetaGraf.Spec.Branch
+	}
+	return "master"
+}
+
+func (g *PipelineGenerator) pipelineParams() []ParamSpec {
+	return []ParamSpec{
+		{Name: "git-url", Description: "Repository to build from.", Default: stringPtr(g.MetaGraf.Spec.Repository)},
+		{Name: "git-revision", Description: "Git revision to build.", Default: stringPtr(g.revision())},
+		{Name: "namespace", Description: "Namespace to deploy to.", Default: stringPtr(g.Options.Namespace)},
+		{Name: "registry", Description: "Container registry host.", Default: stringPtr(g.Options.Registry)},
+		{Name: "image-namespace", Description: "Namespace in the container registry.", Default: stringPtr(g.Options.ImageNS)},
+		{Name: "tag", Description: "Tag for the built image.", Default: stringPtr(g.Options.Tag)},
+	}
+}
+
+func (g *PipelineGenerator) fetchTask() PipelineTask {
+	t := PipelineTask{
+		Name:    fetchTaskName,
+		TaskRef: &TaskRef{Name: g.Options.GitCloneTask},
+		Params: []Param{
+			{Name: "url", Value: "$(params.git-url)"},
+			{Name: "revision", Value: "$(params.git-revision)"},
+		},
+		Workspaces: []WorkspacePipelineTaskBinding{
+			{Name: "output", Workspace: sourceWorkspace},
+		},
+	}
+	if len(g.MetaGraf.Spec.RepSecRef) > 0 {
+		t.Workspaces = append(t.Workspaces, WorkspacePipelineTaskBinding{Name: "ssh-directory", Workspace: credentialsWorkspace})
+	}
+	return t
+}
+
+// Returns the build task. A kaniko build when the specification has a
+// dockerfile, otherwise a s2i or buildpacks build of spec.buildimage.
+func (g *PipelineGenerator) buildTask(name string) (PipelineTask, error) {
+	var steps []Step
+	var volumes []corev1.Volume
+
+	switch {
+	case len(g.MetaGraf.Spec.Dockerfile) > 0:
+		steps = g.kanikoSteps()
+	case len(g.MetaGraf.Spec.BuildImage) > 0 && g.Options.BuildStrategy == StrategyS2I:
+		steps = g.s2iSteps()
+		volumes = []corev1.Volume{
+			{Name: generatedSourceVolume, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		}
+	case len(g.MetaGraf.Spec.BuildImage) > 0 && g.Options.BuildStrategy == StrategyBuildpacks:
+		steps = g.buildpacksSteps()
+	case len(g.MetaGraf.Spec.BuildImage) > 0:
+		return PipelineTask{}, errors.New(errUnknownStrategy)
+	default:
+		return PipelineTask{}, errors.New(errNoBuild)
+	}
+
+	workspaces := []WorkspaceDeclaration{{Name: sourceWorkspace}}
+	bindings := []WorkspacePipelineTaskBinding{{Name: sourceWorkspace, Workspace: sourceWorkspace}}
+	for _, s := range g.MetaGraf.Spec.BuildSecret {
+		workspaces = append(workspaces, WorkspaceDeclaration{
+			Name:      s.VolumeName(),
+			MountPath: s.MountPath,
+			ReadOnly:  true,
+		})
+		bindings = append(bindings, WorkspacePipelineTaskBinding{Name: s.VolumeName(), Workspace: s.VolumeName()})
+	}
+
+	return PipelineTask{
+		Name:     buildTaskName,
+		RunAfter: []string{fetchTaskName},
+		TaskSpec: &TaskSpec{
+			Params:     []ParamSpec{{Name: "IMAGE"}},
+			Workspaces: workspaces,
+			Steps:      steps,
+			Volumes:    volumes,
+		},
+		Params: []Param{
+			{Name: "IMAGE", Value: "$(params.registry)/$(params.image-namespace)/" + name + ":$(params.tag)"},
+		},
+		Workspaces: bindings,
+	}, nil
+}
+
+func (g *PipelineGenerator) kanikoStep(dockerfile string, context string) Step {
+	return Step{
+		Container: corev1.Container{
+			Name:  "build-and-push",
+			Image: g.Options.KanikoImage,
+			Args: []string{
+				"--dockerfile=" + dockerfile,
+				"--context=" + context,
+				"--destination=$(params.IMAGE)",
+			},
+			Env: append(g.MetaGraf.KubernetesBuildVars(), corev1.EnvVar{Name: "DOCKER_CONFIG", Value: dockerConfigPath}),
+		},
+	}
+}
+
+func (g *PipelineGenerator) kanikoSteps() []Step {
+	return []Step{
+		g.kanikoStep("$(workspaces.source.path)/"+g.MetaGraf.Spec.Dockerfile, "$(workspaces.source.path)"),
+	}
+}
+
+// Generates a Dockerfile with s2i and builds it with kaniko. Build
+// environment variables are handed to s2i, which passes them on to
+// the assemble script.
+func (g *PipelineGenerator) s2iSteps() []Step {
+	args := []string{
+		"build", "$(workspaces.source.path)", g.MetaGraf.Spec.BuildImage,
+		"--as-dockerfile", generatedSourcePath + "/Dockerfile.gen",
+	}
+	for _, e := range g.MetaGraf.Spec.Environment.Build {
+		// Expanded by Kubernetes from the container environment.
+		args = append(args, "--env", e.Name+"=$("+e.Name+")")
+	}
+
+	mounts := []corev1.VolumeMount{{Name: generatedSourceVolume, MountPath: generatedSourcePath}}
+
+	generate := Step{
+		Container: corev1.Container{
+			Name:         "generate",
+			Image:        g.Options.S2IImage,
+			Command:      []string{"s2i"},
+			Args:         args,
+			Env:          g.MetaGraf.KubernetesBuildVars(),
+			VolumeMounts: mounts,
+		},
+	}
+	build := g.kanikoStep(generatedSourcePath+"/Dockerfile.gen", generatedSourcePath)
+	build.VolumeMounts = mounts
+
+	return []Step{generate, build}
+}
+
+// Builds with the Cloud Native Buildpacks lifecycle found in the
+// spec.buildimage builder image.
+func (g *PipelineGenerator) buildpacksSteps() []Step {
+	return []Step{
+		{
+			Container: corev1.Container{
+				Name:    "create",
+				Image:   g.MetaGraf.Spec.BuildImage,
+				Command: []string{"/cnb/lifecycle/creator"},
+				Args:    []string{"-app=$(workspaces.source.path)", "$(params.IMAGE)"},
+				Env:     append(g.MetaGraf.KubernetesBuildVars(), corev1.EnvVar{Name: "DOCKER_CONFIG", Value: dockerConfigPath}),
+			},
+		},
+	}
+}
+
+// Returns a task that applies the manifests rendered by mg from the
+// specification in the cloned repository.
+func (g *PipelineGenerator) deployTask() PipelineTask {
+	spec := "$(workspaces.source.path)/" + g.Options.SpecPath
+	image := []string{
+		"--registry", "$(params.registry)",
+		"--imagens", "$(params.image-namespace)",
+		"--tag", "$(params.tag)",
+	}
+
+	return PipelineTask{
+		Name:     deployTaskName,
+		RunAfter: []string{buildTaskName},
+		TaskSpec: &TaskSpec{
+			Params: []ParamSpec{
+				{Name: "namespace"},
+				{Name: "registry"},
+				{Name: "image-namespace"},
+				{Name: "tag"},
+			},
+			Workspaces: []WorkspaceDeclaration{{Name: sourceWorkspace, ReadOnly: true}},
+			Steps: []Step{
+				g.mgStep("deployment", append([]string{"create", "deployment", spec, "--namespace", "$(params.namespace)"}, image...)),
+				g.mgStep("service", []string{"create", "service", spec, "--namespace", "$(params.namespace)"}),
+			},
+		},
+		Params: []Param{
+			{Name: "namespace", Value: "$(params.namespace)"},
+			{Name: "registry", Value: "$(params.registry)"},
+			{Name: "image-namespace", Value: "$(params.image-namespace)"},
+			{Name: "tag", Value: "$(params.tag)"},
+		},
+		Workspaces: []WorkspacePipelineTaskBinding{{Name: sourceWorkspace, Workspace: sourceWorkspace}},
+	}
+}
+
+func (g *PipelineGenerator) mgStep(name string, args []string) Step {
+	return Step{
+		Container: corev1.Container{
+			Name:    name,
+			Image:   g.Options.MGImage,
+			Command: []string{"/mg"},
+			Args:    args,
+		},
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// Creates or updates a Pipeline.
+func (g *PipelineGenerator) StorePipeline(obj Pipeline) error {
+	u, err := toUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	client := k8sclient.GetDynamicClient().Resource(pipelineResource).Namespace(g.Options.Namespace)
+	res, err := client.Get(context.TODO(), obj.Name, metav1.GetOptions{})
+	if err == nil {
+		u.SetResourceVersion(res.GetResourceVersion())
+		_, err = client.Update(context.TODO(), u, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		log.Infof("Updated Tekton Pipeline: %v", obj.Name)
+		return nil
+	}
+
+	_, err = client.Create(context.TODO(), u, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("Created Tekton Pipeline: %v", obj.Name)
+	return nil
+}
+
+// Creates a PipelineRun, which starts the Pipeline.
+func (g *PipelineGenerator) CreatePipelineRun(obj PipelineRun) error {
+	u, err := toUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	client := k8sclient.GetDynamicClient().Resource(pipelineRunResource).Namespace(g.Options.Namespace)
+	result, err := client.Create(context.TODO(), u, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("Created Tekton PipelineRun: %v", result.GetName())
+	return nil
+}
+
+func toUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{}
+	err = u.UnmarshalJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func MarshalToYaml(obj interface{}) ([]byte, error) {
+	y, err := yaml.Marshal(obj)
+	if err != nil {
+		return []byte{}, err
+	}
+	return y, nil
+}
+
+func MarshalToJson(obj interface{}) ([]byte, error) {
+	j, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return j, nil
+}
@@ -0,0 +1,135 @@
+package tekton
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+)
+
+func testMetaGraf() metagraf.MetaGraf {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Repository = "git@github.com:example/app.git"
+	mg.Spec.Branch = "develop"
+	mg.Spec.RepSecRef = "git-ssh"
+	mg.Spec.BuildSecret = []metagraf.Secret{{Name: "maven-settings", MountPath: "/secrets/maven"}}
+	return mg
+}
+
+func TestBuildTask(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		buildimage string
+		strategy   string
+		steps      []string
+		image      string
+		valid      bool
+	}{
+		{"kaniko", "Dockerfile", "", StrategyS2I, []string{"build-and-push"}, PipelineOpts.KanikoImage, true},
+		{"dockerfile before buildimage", "Dockerfile", "builder", StrategyBuildpacks, []string{"build-and-push"}, PipelineOpts.KanikoImage, true},
+		{"s2i", "", "registry/s2i-java:11", StrategyS2I, []string{"generate", "build-and-push"}, PipelineOpts.S2IImage, true},
+		{"buildpacks", "", "paketobuildpacks/builder:base", StrategyBuildpacks, []string{"create"}, "paketobuildpacks/builder:base", true},
+		{"unknown strategy", "", "builder", "docker", nil, "", false},
+		{"no build", "", "", StrategyS2I, nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mg := testMetaGraf()
+			mg.Spec.Dockerfile = tt.dockerfile
+			mg.Spec.BuildImage = tt.buildimage
+			opts := PipelineOpts
+			opts.Namespace = "ns"
+			opts.BuildStrategy = tt.strategy
+			g := NewPipelineGenerator(mg, opts)
+
+			p, err := g.Pipeline("appv1")
+			if (err == nil) != tt.valid {
+				t.Fatalf("Pipeline() error = %v, want valid %v", err, tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+
+			build := p.Spec.Tasks[1]
+			if build.Name != buildTaskName {
+				t.Fatalf("Expected the %v task, got %v", buildTaskName, build.Name)
+			}
+			steps := build.TaskSpec.Steps
+			if len(steps) != len(tt.steps) {
+				t.Fatalf("Expected steps %v, got %v", tt.steps, steps)
+			}
+			for i, s := range steps {
+				if s.Name != tt.steps[i] {
+					t.Errorf("Expected step %v, got %v", tt.steps[i], s.Name)
+				}
+			}
+			if steps[0].Image != tt.image {
+				t.Errorf("Expected image %v, got %v", tt.image, steps[0].Image)
+			}
+		})
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	mg := testMetaGraf()
+	mg.Spec.Dockerfile = "Dockerfile"
+	opts := PipelineOpts
+	opts.Namespace = "ns"
+	g := NewPipelineGenerator(mg, opts)
+
+	run := g.PipelineRun("appv1")
+	if run.Spec.PipelineRef == nil || run.Spec.PipelineRef.Name != "appv1" {
+		t.Errorf("Expected a reference to the appv1 Pipeline, got %v", run.Spec.PipelineRef)
+	}
+
+	params := make(map[string]string)
+	for _, p := range run.Spec.Params {
+		params[p.Name] = p.Value
+	}
+	if params["git-url"] != mg.Spec.Repository {
+		t.Errorf("Expected git-url %v, got %v", mg.Spec.Repository, params["git-url"])
+	}
+	if params["git-revision"] != "develop" {
+		t.Errorf("Expected git-revision develop, got %v", params["git-revision"])
+	}
+
+	secrets := make(map[string]string)
+	for _, w := range run.Spec.Workspaces {
+		if w.Secret != nil {
+			secrets[w.Name] = w.Secret.SecretName
+		}
+	}
+	if secrets[credentialsWorkspace] != "git-ssh" {
+		t.Errorf("Expected the %v workspace bound to git-ssh, got %v", credentialsWorkspace, secrets[credentialsWorkspace])
+	}
+	if secrets["vol-maven-settings"] != "maven-settings" {
+		t.Errorf("Expected the vol-maven-settings workspace bound to maven-settings, got %v", secrets["vol-maven-settings"])
+	}
+
+	// Every workspace bound by the run must be declared by the Pipeline.
+	p, err := g.Pipeline("appv1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared := make(map[string]bool)
+	for _, w := range p.Spec.Workspaces {
+		declared[w.Name] = true
+	}
+	for _, w := range run.Spec.Workspaces {
+		if !declared[w.Name] {
+			t.Errorf("Workspace %v is not declared by the Pipeline", w.Name)
+		}
+	}
+
+	// Without a branch the run builds master.
+	mg.Spec.Branch = ""
+	g = NewPipelineGenerator(mg, opts)
+	for _, p := range g.PipelineRun("appv1").Spec.Params {
+		if p.Name == "git-revision" && p.Value != "master" {
+			t.Errorf("Expected git-revision master, got %v", p.Value)
+		}
+	}
+}
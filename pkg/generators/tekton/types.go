@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tekton
+
+// A minimal subset of the tekton.dev/v1beta1 API. Only the fields mg
+// generates are represented, to avoid depending on the Tekton Pipelines
+// module and its large dependency tree.
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const APIVersion = "tekton.dev/v1beta1"
+
+type Pipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PipelineSpec `json:"spec"`
+}
+
+type PipelineSpec struct {
+	Description string                 `json:"description,omitempty"`
+	Params      []ParamSpec            `json:"params,omitempty"`
+	Workspaces  []WorkspaceDeclaration `json:"workspaces,omitempty"`
+	Tasks       []PipelineTask         `json:"tasks"`
+}
+
+type PipelineTask struct {
+	Name       string                         `json:"name"`
+	TaskRef    *TaskRef                       `json:"taskRef,omitempty"`
+	TaskSpec   *TaskSpec                      `json:"taskSpec,omitempty"`
+	RunAfter   []string                       `json:"runAfter,omitempty"`
+	Params     []Param                        `json:"params,omitempty"`
+	Workspaces []WorkspacePipelineTaskBinding `json:"workspaces,omitempty"`
+}
+
+type TaskRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+type TaskSpec struct {
+	Params     []ParamSpec            `json:"params,omitempty"`
+	Workspaces []WorkspaceDeclaration `json:"workspaces,omitempty"`
+	Steps      []Step                 `json:"steps"`
+	Volumes    []corev1.Volume        `json:"volumes,omitempty"`
+}
+
+// A Step is a container with an optional script.
+type Step struct {
+	corev1.Container
+	Script string `json:"script,omitempty"`
+}
+
+type ParamSpec struct {
+	Name        string  `json:"name"`
+	Type        string  `json:"type,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Default     *string `json:"default,omitempty"`
+}
+
+// Only string values are supported.
+type Param struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type WorkspaceDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MountPath   string `json:"mountPath,omitempty"`
+	ReadOnly    bool   `json:"readOnly,omitempty"`
+	Optional    bool   `json:"optional,omitempty"`
+}
+
+type WorkspacePipelineTaskBinding struct {
+	Name      string `json:"name"`
+	Workspace string `json:"workspace"`
+	SubPath   string `json:"subPath,omitempty"`
+}
+
+type PipelineRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PipelineRunSpec `json:"spec"`
+}
+
+type PipelineRunSpec struct {
+	PipelineRef        *PipelineRef       `json:"pipelineRef,omitempty"`
+	Params             []Param            `json:"params,omitempty"`
+	ServiceAccountName string             `json:"serviceAccountName,omitempty"`
+	Workspaces         []WorkspaceBinding `json:"workspaces,omitempty"`
+}
+
+type PipelineRef struct {
+	Name string `json:"name"`
+}
+
+type WorkspaceBinding struct {
+	Name                string                        `json:"name"`
+	SubPath             string                        `json:"subPath,omitempty"`
+	VolumeClaimTemplate *corev1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+	EmptyDir            *corev1.EmptyDirVolumeSource  `json:"emptyDir,omitempty"`
+	Secret              *corev1.SecretVolumeSource    `json:"secret,omitempty"`
+}
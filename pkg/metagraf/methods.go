@@ -50,6 +50,24 @@ func (mg MetaGraf) GetResourceByName(name string) (Resource, error) {
 	return Resource{}, errors.New("Resource{} not found, name: " + name)
 }
 
+// Returns the kind of Service addressing an attached resource in the
+// cluster, or an empty ResourceType if it needs none. External resources
+// with a host get an ExternalName Service, resources with fixed addresses
+// a Service with Endpoints.
+func (r Resource) ServiceType() ResourceType {
+	switch ResourceType(r.Type) {
+	case ExternalName, ClusterService:
+		return ResourceType(r.Type)
+	}
+	if r.External && len(r.Host) > 0 {
+		return ExternalName
+	}
+	if len(r.Addresses) > 0 {
+		return ClusterService
+	}
+	return ""
+}
+
 //
 func (mg MetaGraf) GetSecretByName(name string) (Secret, error) {
 	for _, s := range mg.Spec.Secret {
@@ -1,6 +1,10 @@
 package metagraf
 
-import "github.com/pkg/errors"
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 // Returns a metagraf adressable key for a property.
 func (mgp *MGProperty) MGKey() string {
@@ -94,8 +98,8 @@ func (mg *MetaGraf) GetProperties() MGProperties {
 			continue
 		}
 
-		switch conf.Type {
-		case "parameters":
+		switch {
+		case conf.Type == "parameters" || strings.ToUpper(conf.Type) == "RUNTIME":
 			for _, opts := range conf.Options {
 				p := MGProperty{
 					Source:   conf.Name,
@@ -106,7 +110,7 @@ func (mg *MetaGraf) GetProperties() MGProperties {
 				}
 				props[p.MGKey()] = p
 			}
-		case "JVM_SYS_PROP":
+		case conf.Type == "JVM_SYS_PROP":
 			for _, opts := range conf.Options {
 				p := MGProperty{
 					Source:   "JVM_SYS_PROP",
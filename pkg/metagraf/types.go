@@ -18,6 +18,7 @@ package metagraf
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Structure to hold specification section sourced parameters from input. Should
@@ -129,9 +130,259 @@ type MetaGraf struct {
 
 		// Slice of metagraf.Secret's needed in build context.
 		BuildSecret []Secret `json:"buildsecret,omitempty"`
+
+		// Alerting rules for the component. Used for generating PrometheusRule resources.
+		Alerts Alerts `json:"alerts,omitempty"`
+
+		// Exposure of the component through OpenShift Routes.
+		Route *Route `json:"route,omitempty"`
+
+		// Shapes the generated Service. Defaults to a ClusterIP Service.
+		Service *Service `json:"service,omitempty"`
+
+		// Where the pods of the component are scheduled.
+		Scheduling *Scheduling `json:"scheduling,omitempty"`
+
+		// How new versions of the component are rolled out. Defaults to a
+		// rolling update with 25% surge and 25% unavailable.
+		Rollout *Rollout `json:"rollout,omitempty"`
 	} `json:"spec"`
 }
 
+// Describes how new versions of a component are rolled out. Canary and
+// BlueGreen generate Argo Rollouts instead of Deployments or DeploymentConfigs.
+type Rollout struct {
+	// RollingUpdate, Recreate, Canary or BlueGreen. Defaults to RollingUpdate.
+	Strategy string `json:"strategy,omitempty"`
+	// Pods above the replica count during a rolling update or canary, a
+	// number or a percentage. Defaults to 25%.
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// Unavailable pods during a rolling update or canary, a number or a
+	// percentage. Defaults to 25%.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	Canary         *CanaryRollout      `json:"canary,omitempty"`
+	BlueGreen      *BlueGreenRollout   `json:"blueGreen,omitempty"`
+	// Analysis of the new version from Prometheus metrics, generating an
+	// AnalysisTemplate.
+	Analysis *RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+type CanaryRollout struct {
+	Steps []CanaryStep `json:"steps,omitempty"`
+}
+
+// A step of a canary rollout. Sets the weight of the canary, pauses or
+// runs the analysis.
+type CanaryStep struct {
+	// Percentage of pods running the new version.
+	SetWeight *int32 `json:"setWeight,omitempty"`
+	// Pause duration, example 30s or 5m. An empty duration pauses until
+	// the rollout is promoted.
+	Pause *string `json:"pause,omitempty"`
+	// Run the analysis in this step. Without analysis steps the analysis
+	// runs in the background during the whole canary.
+	Analysis bool `json:"analysis,omitempty"`
+}
+
+// Blue-green rollouts switch the active Service to the new version on
+// promotion. The new version is reachable through the preview Service,
+// named after the component with a -preview suffix, before that.
+type BlueGreenRollout struct {
+	// Promote automatically when the new version is ready. Defaults to true.
+	AutoPromotionEnabled *bool `json:"autoPromotionEnabled,omitempty"`
+	// Seconds to wait before automatic promotion.
+	AutoPromotionSeconds int32 `json:"autoPromotionSeconds,omitempty"`
+	// Seconds before the old version is scaled down after promotion.
+	ScaleDownDelaySeconds *int32 `json:"scaleDownDelaySeconds,omitempty"`
+}
+
+type RolloutAnalysis struct {
+	// Address of the Prometheus scraping the ServiceMonitor of the
+	// component, example http://prometheus-operated.monitoring:9090.
+	PrometheusAddress string `json:"prometheusAddress"`
+	// Metrics to query. Defaults to requiring all scrape targets of the
+	// component to be up.
+	Metrics []AnalysisMetric `json:"metrics,omitempty"`
+}
+
+// A Prometheus query of an analysis. Queries can use {{args.service-name}}
+// and {{args.namespace}}, the job and namespace labels of the metrics
+// scraped through the ServiceMonitor.
+type AnalysisMetric struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	// Condition on the query result, example result[0] >= 0.95.
+	SuccessCondition string `json:"successCondition"`
+	// Time between measurements, example 1m.
+	Interval string `json:"interval,omitempty"`
+	// Number of measurements.
+	Count *int32 `json:"count,omitempty"`
+	// Failed measurements tolerated before the analysis fails.
+	FailureLimit *int32 `json:"failureLimit,omitempty"`
+}
+
+// Describes where the pods of a component are scheduled. Applied by all
+// workload generators. The --with-affinity-rules flags override AntiAffinity.
+type Scheduling struct {
+	// Anti-affinity between the pods of the component.
+	AntiAffinity *AntiAffinity `json:"antiAffinity,omitempty"`
+	// Node labels selecting node pools for the pods.
+	NodeAffinity *NodeAffinity `json:"nodeAffinity,omitempty"`
+	// Tolerations of the pods, for running on tainted node pools.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// Spreads the pods of the component across zones, hosts or other
+	// topology domains.
+	TopologySpread []TopologySpread `json:"topologySpread,omitempty"`
+}
+
+type AntiAffinity struct {
+	// soft or hard. Defaults to soft.
+	Type string `json:"type,omitempty"`
+	// Node label describing the topology domain, example kubernetes.io/hostname.
+	TopologyKey string `json:"topologyKey"`
+	// Weight of soft anti-affinity, 1-100. Defaults to 100.
+	Weight int32 `json:"weight,omitempty"`
+}
+
+type NodeAffinity struct {
+	// Node labels the pods must be scheduled on.
+	Required map[string]string `json:"required,omitempty"`
+	// Node labels the pods are preferably scheduled on.
+	Preferred map[string]string `json:"preferred,omitempty"`
+	// Weight of the preferred labels, 1-100. Defaults to 100.
+	Weight int32 `json:"weight,omitempty"`
+}
+
+type TopologySpread struct {
+	// Node label describing the topology domain, example topology.kubernetes.io/zone.
+	TopologyKey string `json:"topologyKey"`
+	// Maximum difference in number of pods between domains. Defaults to 1.
+	MaxSkew int32 `json:"maxSkew,omitempty"`
+	// DoNotSchedule or ScheduleAnyway. Defaults to ScheduleAnyway.
+	WhenUnsatisfiable v1.UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
+}
+
+// Describes the Service of a component. Ports are still derived from
+// spec.ports, annotations and the image.
+type Service struct {
+	// ClusterIP, NodePort or LoadBalancer. Defaults to ClusterIP.
+	Type v1.ServiceType `json:"type,omitempty"`
+	// Generate a headless Service, without a cluster ip, as used by StatefulSets.
+	Headless bool `json:"headless,omitempty"`
+	// Publish addresses of pods before they are ready.
+	PublishNotReadyAddresses bool `json:"publishNotReadyAddresses,omitempty"`
+	// Cluster or Local. Only used with NodePort and LoadBalancer.
+	ExternalTrafficPolicy v1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+	// None or ClientIP. Defaults to None.
+	SessionAffinity v1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+	// Seconds a client sticks to a pod with ClientIP session affinity.
+	SessionAffinityTimeout *int32 `json:"sessionAffinityTimeout,omitempty"`
+	// Node ports keyed by service port name. Allocated by the cluster if missing.
+	NodePorts map[string]int32 `json:"nodePorts,omitempty"`
+	// Client ranges allowed through a cloud load balancer.
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// Additional selector labels, for selecting a subset of the pods.
+	Selector map[string]string `json:"selector,omitempty"`
+	// Annotations on the Service, example cloud load balancer configuration.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Describes how a component is exposed through OpenShift Routes.
+type Route struct {
+	// Hostname of the route. Generated by the router if empty.
+	Host string `json:"host,omitempty"`
+	// Name of the service port to route to. Defaults to http or the first service port.
+	Port string `json:"port,omitempty"`
+	// Weight of the current version when AlternateBackends are present. Defaults to 100.
+	Weight *int32    `json:"weight,omitempty"`
+	TLS    *RouteTLS `json:"tls,omitempty"`
+	// Additional path based routes, each generating a separate Route.
+	Paths []RoutePath `json:"paths,omitempty"`
+	// Other versions of the component sharing traffic with the current
+	// version, for blue/green deployments between semver majors.
+	AlternateBackends []RouteBackend `json:"alternateBackends,omitempty"`
+}
+
+// TLS configuration of a Route. Certificates are read from Secrets when the
+// Route is generated, from the tls.crt, tls.key and ca.crt keys.
+type RouteTLS struct {
+	// edge, reencrypt or passthrough
+	Termination string `json:"termination"`
+	// Allow, Redirect or None. How plain http requests are handled.
+	InsecureEdgeTerminationPolicy string `json:"insecureEdgeTerminationPolicy,omitempty"`
+	// Secret holding the certificate, key and CA certificate of the route.
+	SecretRef string `json:"secretRef,omitempty"`
+	// Secret holding the CA certificate of the backend in ca.crt, for reencrypt.
+	DestinationCASecretRef string `json:"destinationCASecretRef,omitempty"`
+}
+
+// A path based route.
+type RoutePath struct {
+	// Appended to the name of the generated Route.
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Defaults to Route.Host.
+	Host string `json:"host,omitempty"`
+	// Defaults to Route.Port.
+	Port string `json:"port,omitempty"`
+}
+
+// A version of the component to route part of the traffic to.
+type RouteBackend struct {
+	// Semantic version of the component, the major version selects the Service.
+	Version string `json:"version"`
+	Weight  int32  `json:"weight"`
+}
+
+// Describes alerting rules for a component. The availability and latency
+// sections generates common SLO rules from conventional http server metrics,
+// Custom is for alerting rules with handwritten expressions.
+type Alerts struct {
+	Availability *AvailabilityAlert `json:"availability,omitempty"`
+	Latency      *LatencyAlert      `json:"latency,omitempty"`
+	Custom       []Alert            `json:"custom,omitempty"`
+}
+
+// Alert when the ratio of failing requests exceeds what the objective allows.
+type AvailabilityAlert struct {
+	// Objective in percent, example: 99.9
+	Objective float64 `json:"objective"`
+	// Name of request counter metric. Defaults to http_server_requests_seconds_count.
+	Metric string `json:"metric,omitempty"`
+	// Label matcher identifying failed requests. Defaults to status=~"5..".
+	ErrorSelector string `json:"errorselector,omitempty"`
+	// Range vector window used for rate calculation. Defaults to 5m.
+	Window   string `json:"window,omitempty"`
+	For      string `json:"for,omitempty"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// Alert when a request duration percentile exceeds the threshold.
+type LatencyAlert struct {
+	// Percentile as a ratio, example: 0.99
+	Percentile float64 `json:"percentile"`
+	// Threshold in seconds.
+	Threshold float64 `json:"threshold"`
+	// Basename of request duration histogram. Defaults to http_server_requests_seconds.
+	Metric string `json:"metric,omitempty"`
+	// Range vector window used for rate calculation. Defaults to 5m.
+	Window   string `json:"window,omitempty"`
+	For      string `json:"for,omitempty"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// A custom alerting rule.
+type Alert struct {
+	Name string `json:"name"`
+	// PromQL expression.
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Severity    string            `json:"severity,omitempty"`
+	Summary     string            `json:"summary,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
 // Describes attached resources for a component. Ref, 12 factor app.
 // This section is currently a mess because of "lift and shift" approach
 // we  should never have done. Going forward all attached resources
@@ -153,6 +404,13 @@ type Resource struct {
 	ConfigRef   string `json:"configref,omitempty"`   // ConfigMap Reference, Replaces TemplateRef which was not a good name.
 	User        string `json:"user,omitempty"`
 	Secret      string `json:"secret,omitempty"` // k8s Secret reference
+
+	// Hostname of an external resource, addressed through an ExternalName Service.
+	Host string `json:"host,omitempty"`
+	// Fixed ip addresses of the resource, addressed through a Service with Endpoints.
+	Addresses []string `json:"addresses,omitempty"`
+	// Ports of the resource keyed by name.
+	Ports map[string]int32 `json:"ports,omitempty"`
 }
 
 type Config struct {
@@ -164,6 +422,17 @@ type Config struct {
 	MountPath   string        `json:"mountpath,omitempty"`
 	Description string        `json:"description,omitempty"`
 	Options     []ConfigParam `json:"options,omitempty"`
+	// Environment variable receiving the options of a JVM_SYS_PROP or
+	// RUNTIME config. Defaults to the JVM_SYS_PROP environment variables,
+	// or the default target of the runtime profile.
+	Target string `json:"target,omitempty"`
+
+	// Maps individual keys of the ConfigMap to file names.
+	Items []v1.KeyToPath `json:"items,omitempty"`
+	// Mount a single file from the ConfigMap at MountPath.
+	SubPath string `json:"subpath,omitempty"`
+	// File mode of the mounted files. Defaults to 0644.
+	Mode *int32 `json:"mode,omitempty"`
 }
 
 type ConfigParam struct {
@@ -186,6 +455,45 @@ type Secret struct {
 
 	// Using Kubernetes v1.KeyToPath struct for mapping individual secret key's to filenames.
 	Items []v1.KeyToPath `json:"items,omitempty"`
+	// Mount a single file from the Secret at MountPath.
+	SubPath string `json:"subpath,omitempty"`
+	// File mode of the mounted files. Defaults to 0644.
+	Mode *int32 `json:"mode,omitempty"`
+
+	// Generates the contents of the Secret when it is created.
+	Generator *SecretGenerator `json:"generator,omitempty"`
+
+	// Key of the secret in an external secret store. Defaults to
+	// <namespace>/<secret name>.
+	ExternalKey string `json:"externalkey,omitempty"`
+}
+
+// Types of generated Secrets.
+const (
+	SecretGeneratorRandom       = "random"
+	SecretGeneratorTLS          = "tls"
+	SecretGeneratorSSH          = "ssh"
+	SecretGeneratorDockerConfig = "dockerconfig"
+)
+
+// Describes how the contents of a Secret are generated.
+type SecretGenerator struct {
+	// random, tls, ssh or dockerconfig.
+	Type string `json:"type"`
+	// Key holding a random value. Defaults to password.
+	Key string `json:"key,omitempty"`
+	// Length of a random value. Defaults to 32.
+	Length int `json:"length,omitempty"`
+	// Characters of a random value, alphanumeric, hex, ascii or a literal
+	// set of characters. Defaults to alphanumeric.
+	Charset string `json:"charset,omitempty"`
+	// Subject alternative names of a tls certificate, in addition to the
+	// Service and Route hostnames of the component.
+	Hosts []string `json:"hosts,omitempty"`
+	// Validity of a tls certificate in days. Defaults to 365.
+	ValidDays int `json:"validdays,omitempty"`
+	// Size of generated RSA keys. Defaults to 2048 for tls and 4096 for ssh.
+	Bits int `json:"bits,omitempty"`
 }
 
 type EnvironmentVar struct {
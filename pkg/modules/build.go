@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	buildv1 "github.com/openshift/api/build/v1"
+	"github.com/openshift/client-go/build/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	log "k8s.io/klog"
+)
+
+// How long to wait for a build to start or finish.
+var (
+	BuildStartTimeout = 10 * time.Minute
+	BuildTimeout      = 2 * time.Hour
+)
+
+// Starts a build from the named BuildConfig.
+func StartBuild(name string) (*buildv1.Build, error) {
+	br := buildv1.BuildRequest{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "BuildRequest",
+			APIVersion: "build.openshift.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		TriggeredBy: []buildv1.BuildTriggerCause{
+			{Message: "Triggered by mg."},
+		},
+	}
+
+	client := k8sclient.GetBuildClient().BuildConfigs(NameSpace)
+	build, err := client.Instantiate(context.TODO(), name, &br, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Started build: ", build.Name, " in Namespace: ", NameSpace)
+	return build, nil
+}
+
+// Starts a binary build from the named BuildConfig, uploading the contents
+// of r as the build input. r should be a tar stream of the build context.
+func StartBinaryBuild(name string, r io.Reader) (*buildv1.Build, error) {
+	opts := buildv1.BinaryBuildRequestOptions{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+
+	build := &buildv1.Build{}
+	err := k8sclient.GetBuildClient().RESTClient().Post().
+		Namespace(NameSpace).
+		Resource("buildconfigs").
+		Name(name).
+		SubResource("instantiatebinary").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(r).
+		Do(context.TODO()).
+		Into(build)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Started binary build: ", build.Name, " in Namespace: ", NameSpace)
+	return build, nil
+}
+
+// Streams the log of the named build to w and waits for it to finish.
+// Returns an error unless the build completed successfully.
+func FollowBuild(name string, w io.Writer) error {
+	client := k8sclient.GetBuildClient()
+
+	// Logs are available once the build pod is running.
+	err := wait.PollImmediate(time.Second, BuildStartTimeout, func() (bool, error) {
+		build, err := client.Builds(NameSpace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return build.Status.Phase != buildv1.BuildPhaseNew && build.Status.Phase != buildv1.BuildPhasePending, nil
+	})
+	if err != nil {
+		return fmt.Errorf("build %v did not start: %v", name, err)
+	}
+
+	opts := buildv1.BuildLogOptions{Follow: true}
+	stream, err := client.RESTClient().Get().
+		Namespace(NameSpace).
+		Resource("builds").
+		Name(name).
+		SubResource("log").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Stream(context.TODO())
+	if err != nil {
+		log.Warningf("Unable to follow log for build %v: %v", name, err)
+	} else {
+		defer stream.Close()
+		_, err = io.Copy(w, stream)
+		if err != nil {
+			log.Warningf("Log stream for build %v ended: %v", name, err)
+		}
+	}
+
+	var build *buildv1.Build
+	err = wait.PollImmediate(time.Second, BuildTimeout, func() (bool, error) {
+		var err error
+		build, err = client.Builds(NameSpace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return buildCompleted(build), nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for build %v: %v", name, err)
+	}
+
+	if build.Status.Phase != buildv1.BuildPhaseComplete {
+		return fmt.Errorf("build %v %v: %v", name, build.Status.Phase, build.Status.Message)
+	}
+	return nil
+}
+
+func buildCompleted(build *buildv1.Build) bool {
+	switch build.Status.Phase {
+	case buildv1.BuildPhaseComplete, buildv1.BuildPhaseFailed, buildv1.BuildPhaseError, buildv1.BuildPhaseCancelled:
+		return true
+	}
+	return false
+}
@@ -22,7 +22,7 @@ import (
 	"os"
 	"strings"
 
-	"github.com/laetho/metagraf/internal/pkg/helpers"
+	"github.com/laetho/metagraf/internal/pkg/imageinfo"
 	"github.com/laetho/metagraf/internal/pkg/imageurl"
 	"github.com/laetho/metagraf/internal/pkg/k8sclient"
 	"github.com/laetho/metagraf/internal/pkg/params"
@@ -33,90 +33,27 @@ import (
 	buildv1 "github.com/openshift/api/build/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	//"github.com/openshift/oc/pkg/helpers/source-to-image/tar"
 )
 
-func TriggerLocalBuild(mg metagraf.MetaGraf) {
-/*
-	br := buildv1.BuildRequest{
-		TypeMeta:              metav1.TypeMeta{},
-		ObjectMeta:            metav1.ObjectMeta{},
-	}
-	br.Kind = "BuildRequest"
-	br.APIVersion = "build.openshift.io/v1"
-	br.ObjectMeta.Name = mg.Name("","")
-	brt := buildv1.BuildTriggerCause{}
-	brt.Message = "Triggered by mg."
-	br.TriggeredBy = []buildv1.BuildTriggerCause{brt}
-	client := k8sclient.GetBuildClient()
-	build, err := client.BuildConfigs(params.NameSpace).Instantiate(context.TODO(), br.ObjectMeta.Name,&br, metav1.CreateOptions{})
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	fmt.Println("Started", build.Name)
-
-	tar.
- */
-}
+// Label holding the branch name on per branch BuildConfigs.
+const BranchLabel = "branch"
 
 func GenBuildConfig(mg *metagraf.MetaGraf) {
 	var buildsource buildv1.BuildSource
-	var imgurl imageurl.ImageURL
-	var EnvVars []corev1.EnvVar
-
-	err := imgurl.Parse(mg.Spec.BuildImage)
-	if err != nil {
-
-		log.Errorf("Malformed BuildImage url provided in metaGraf file; %v", mg.Spec.BuildImage)
-		os.Exit(1)
-	}
+	var strategy buildv1.BuildStrategy
 
 	objname := Name(mg)
+	bcname := objname
 
-	if len(mg.Spec.BaseRunImage) > 0 && len(mg.Spec.Repository) > 0 {
-		buildsource = genBinaryBuildSource()
-	} else if len(mg.Spec.BuildImage) > 0 && len(mg.Spec.BaseRunImage) < 1 {
-		buildsource = genGitBuildSource(mg)
-	}
-
-	if BaseEnvs {
-		log.V(2).Info("Populate environment variables form base image.")
-		client := k8sclient.GetImageClient()
-
-		ist := helpers.GetImageStreamTags(
-			client,
-			imgurl.Namespace,
-			imgurl.Image+":"+imgurl.Tag)
-
-		ImageInfo := helpers.GetDockerImageFromIST(ist)
-
-		// Environment Variables from buildimage
-		for _, e := range ImageInfo.Config.Env {
-			es := strings.Split(e, "=")
-			if helpers.SliceInString(EnvBlacklistFilter, strings.ToLower(es[0])) {
-				continue
-			}
-			EnvVars = append(EnvVars, corev1.EnvVar{Name: es[0], Value: es[1]})
-		}
+	if len(mg.Spec.Dockerfile) > 0 {
+		buildsource, strategy = genDockerBuild(mg)
+	} else {
+		buildsource, strategy = genSourceBuild(mg)
 	}
 
 	// Resource labels
 	l := Labels(objname, labelsFromParams(params.Labels))
 
-	km := Variables.KeyMap()
-	for _, e := range mg.Spec.Environment.Build {
-		if e.Required == true {
-			if len(km[e.Name]) > 0 {
-				EnvVars = append(EnvVars, corev1.EnvVar{Name: e.Name, Value: km[e.Name]})
-			} else {
-				EnvVars = append(EnvVars, corev1.EnvVar{Name: e.Name, Value: e.Default})
-			}
-		} else if e.Required == false {
-			EnvVars = append(EnvVars, corev1.EnvVar{Name: e.Name, Value: "null"})
-		}
-	}
-
 	// Construct toObjRef for BuildConfig output overrides
 	var toObjRefName = objname
 	var toObjRefTag = "latest"
@@ -126,6 +63,19 @@ func GenBuildConfig(mg *metagraf.MetaGraf) {
 	if len(Tag) > 0 {
 		toObjRefTag = Tag
 	}
+
+	// Per branch BuildConfigs are named and tagged after the branch.
+	if params.BranchBuildConfig {
+		branch := BranchName(buildBranch(mg))
+		if len(branch) == 0 {
+			log.Error("A branch is required for per branch BuildConfigs, provide one with --ref or .spec.branch")
+			os.Exit(1)
+		}
+		bcname = objname + "-" + branch
+		toObjRefTag = branch
+		l[BranchLabel] = branch
+	}
+
 	var toObjRef = &corev1.ObjectReference{
 		Kind: "ImageStreamTag",
 		Name: toObjRefName + ":" + toObjRefTag,
@@ -137,24 +87,14 @@ func GenBuildConfig(mg *metagraf.MetaGraf) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   objname,
+			Name:   bcname,
 			Labels: l,
 		},
 		Spec: buildv1.BuildConfigSpec{
 			RunPolicy: buildv1.BuildRunPolicySerial,
 			CommonSpec: buildv1.CommonSpec{
-				Source: buildsource,
-				Strategy: buildv1.BuildStrategy{
-					Type: buildv1.SourceBuildStrategyType,
-					SourceStrategy: &buildv1.SourceBuildStrategy{
-						Env: EnvVars,
-						From: corev1.ObjectReference{
-							Kind:      "ImageStreamTag",
-							Namespace: imgurl.Namespace,
-							Name:      imgurl.Image + ":" + imgurl.Tag,
-						},
-					},
-				},
+				Source:   buildsource,
+				Strategy: strategy,
 				Output: buildv1.BuildOutput{
 					To: toObjRef,
 				},
@@ -170,6 +110,137 @@ func GenBuildConfig(mg *metagraf.MetaGraf) {
 	}
 }
 
+// Source and s2i strategy for building from .spec.buildimage.
+func genSourceBuild(mg *metagraf.MetaGraf) (buildv1.BuildSource, buildv1.BuildStrategy) {
+	var buildsource buildv1.BuildSource
+	var imgurl imageurl.ImageURL
+	var EnvVars []corev1.EnvVar
+
+	err := imgurl.Parse(mg.Spec.BuildImage)
+	if err != nil {
+
+		log.Errorf("Malformed BuildImage url provided in metaGraf file; %v", mg.Spec.BuildImage)
+		os.Exit(1)
+	}
+
+	if len(mg.Spec.BaseRunImage) > 0 && len(mg.Spec.Repository) > 0 {
+		buildsource = genBinaryBuildSource()
+	} else if len(mg.Spec.BuildImage) > 0 && len(mg.Spec.BaseRunImage) < 1 {
+		buildsource = genGitBuildSource(mg)
+	}
+
+	if BaseEnvs {
+		log.V(2).Info("Populate environment variables form base image.")
+		p, err := imageinfo.NewProvider()
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		ImageInfo, err := p.ImageInfo(mg.Spec.BuildImage)
+		if err != nil {
+			log.Errorf("Unable to read image metadata for %v: %v", mg.Spec.BuildImage, err)
+			os.Exit(1)
+		}
+
+		// Environment Variables from buildimage
+		EnvVars = append(EnvVars, ImageInfo.EnvVars(EnvBlacklistFilter)...)
+	}
+
+	km := Variables.KeyMap()
+	for _, e := range mg.Spec.Environment.Build {
+		if e.Required == true {
+			if len(km[e.Name]) > 0 {
+				EnvVars = append(EnvVars, corev1.EnvVar{Name: e.Name, Value: km[e.Name]})
+			} else {
+				EnvVars = append(EnvVars, corev1.EnvVar{Name: e.Name, Value: e.Default})
+			}
+		} else if e.Required == false {
+			EnvVars = append(EnvVars, corev1.EnvVar{Name: e.Name, Value: "null"})
+		}
+	}
+
+	strategy := buildv1.BuildStrategy{
+		Type: buildv1.SourceBuildStrategyType,
+		SourceStrategy: &buildv1.SourceBuildStrategy{
+			Env: EnvVars,
+			From: corev1.ObjectReference{
+				Kind:      "ImageStreamTag",
+				Namespace: imgurl.Namespace,
+				Name:      imgurl.Image + ":" + imgurl.Tag,
+			},
+		},
+	}
+	return buildsource, strategy
+}
+
+// Source and docker strategy for building from .spec.dockerfile. Build
+// environment variables become build args and build secrets are made
+// available in the build context.
+func genDockerBuild(mg *metagraf.MetaGraf) (buildv1.BuildSource, buildv1.BuildStrategy) {
+	var buildsource buildv1.BuildSource
+	if len(mg.Spec.Repository) > 0 {
+		buildsource = genGitBuildSource(mg)
+	} else {
+		buildsource = genBinaryBuildSource()
+	}
+
+	for _, s := range mg.Spec.BuildSecret {
+		buildsource.Secrets = append(buildsource.Secrets, buildv1.SecretBuildSource{
+			Secret: corev1.LocalObjectReference{Name: s.Name},
+			// Must be relative to the build context.
+			DestinationDir: strings.TrimPrefix(s.MountPath, "/"),
+		})
+	}
+
+	var args []corev1.EnvVar
+	km := Variables.KeyMap()
+	for _, e := range mg.Spec.Environment.Build {
+		value := e.Default
+		if len(km[e.Name]) > 0 {
+			value = km[e.Name]
+		}
+		if len(value) == 0 {
+			continue
+		}
+		args = append(args, corev1.EnvVar{Name: e.Name, Value: value})
+	}
+
+	strategy := buildv1.BuildStrategy{
+		Type: buildv1.DockerBuildStrategyType,
+		DockerStrategy: &buildv1.DockerBuildStrategy{
+			DockerfilePath: mg.Spec.Dockerfile,
+			BuildArgs:      args,
+		},
+	}
+	return buildsource, strategy
+}
+
+// Returns the branch we are building from, --ref overrides .spec.branch.
+func buildBranch(mg *metagraf.MetaGraf) string {
+	if len(params.SourceRef) > 0 {
+		return params.SourceRef
+	}
+	return mg.Spec.Branch
+}
+
+// Turns a git branch name into something usable in resource names, labels
+// and ImageStream tags. feature/JIRA-123 becomes feature-jira-123.
+func BranchName(branch string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(branch) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if len(name) > 40 {
+		name = strings.TrimRight(name[:40], "-")
+	}
+	return name
+}
+
 func genBinaryBuildSource() buildv1.BuildSource {
 	return buildv1.BuildSource{
 		Type:   "Source",
@@ -178,12 +249,7 @@ func genBinaryBuildSource() buildv1.BuildSource {
 }
 
 func genGitBuildSource(mg *metagraf.MetaGraf) buildv1.BuildSource {
-	var branch string
-	if len(params.SourceRef) > 0 {
-		branch = params.SourceRef
-	} else {
-		branch = mg.Spec.Branch
-	}
+	branch := buildBranch(mg)
 
 	bs := buildv1.BuildSource{
 		Type: "Git",
@@ -241,3 +307,39 @@ func DeleteBuildConfig(name string) {
 	}
 	fmt.Println("Deleted BuildConfig: ", name, ", in namespace: ", NameSpace)
 }
+
+// Deletes per branch BuildConfigs, and their ImageStream tags, for branches
+// not in the branches slice.
+func DeleteStaleBranchBuildConfigs(mg *metagraf.MetaGraf, branches []string) {
+	objname := Name(mg)
+
+	keep := make(map[string]bool)
+	for _, b := range branches {
+		keep[BranchName(b)] = true
+	}
+
+	client := k8sclient.GetBuildClient().BuildConfigs(NameSpace)
+	bcs, err := client.List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "app=" + objname + "," + BranchLabel,
+	})
+	if err != nil {
+		log.Error(err)
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	for _, bc := range bcs.Items {
+		branch := bc.Labels[BranchLabel]
+		if keep[branch] {
+			continue
+		}
+		if Dryrun {
+			fmt.Println("Would delete BuildConfig: ", bc.Name, " in Namespace: ", NameSpace)
+			continue
+		}
+		DeleteBuildConfig(bc.Name)
+		if bc.Spec.Output.To != nil && bc.Spec.Output.To.Kind == "ImageStreamTag" {
+			DeleteImageStreamTag(bc.Spec.Output.To.Name)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package modules
+
+import "testing"
+
+func TestBranchName(t *testing.T) {
+	tests := map[string]string{
+		"master":                    "master",
+		"feature/JIRA-123":          "feature-jira-123",
+		"bugfix/fix_stuff.":         "bugfix-fix-stuff",
+		"dependabot/npm/lodash-4.x": "dependabot-npm-lodash-4-x",
+	}
+	for branch, expected := range tests {
+		t.Run(branch, func(t *testing.T) {
+			if name := BranchName(branch); name != expected {
+				t.Errorf("Expected %v, got %v", expected, name)
+			}
+		})
+	}
+}
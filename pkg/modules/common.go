@@ -22,11 +22,11 @@ import (
 	"strings"
 
 	"github.com/blang/semver"
+	"github.com/laetho/metagraf/internal/pkg/imageinfo"
 	"github.com/laetho/metagraf/pkg/metagraf"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	log "k8s.io/klog"
-	"k8s.io/klog/v2"
 
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -120,17 +120,9 @@ func GetEnvVars(mg *metagraf.MetaGraf, inputprops metagraf.MGProperties) []corev
 
 	outputevars = append(outputevars, GetMGEnvVars(mg)...)
 
-	// If the spec has any JVM_SYS_PROP variable generate and inject values from
-	// input properties.
-	if HasJVM_SYS_PROP(mg) {
-		envvars := mg.GetEnvVarByType("JVM_SYS_PROP")
-		if len(envvars) > 1 {
-			klog.Warning("Only one variable of JVM_SYS_PROP is supported. If you have multiple all JVM_SYS_PROP definitions will get duplicated.")
-		}
-		for _, env := range envvars {
-			outputevars = append(outputevars, GenEnvVar_JVM_SYS_PROP(inputprops, env.Name))
-		}
-	}
+	// Generate options of JVM_SYS_PROP and RUNTIME configs and annotations
+	// for the runtime profile from input properties.
+	outputevars = append(outputevars, runtimeEnvVars(mg, inputprops)...)
 
 	// Create corev1.EnvVar for all .spec.environment.local of with type secretfrom or envfrom.
 	for _, e := range specenvs {
@@ -170,9 +162,23 @@ func GetEnvVars(mg *metagraf.MetaGraf, inputprops metagraf.MGProperties) []corev
 			outputevars = append(outputevars, envvar)
 		}
 	}
+
+	outputevars = append(outputevars, resourceTemplateEnvVars(mg)...)
+	outputevars = append(outputevars, globalConfigEnvVars(mg)...)
 	return outputevars
 }
 
+// Reads metadata of the image referenced in the specification. Returns
+// false if it could not be read.
+func mgImageInfo(mg *metagraf.MetaGraf) (imageinfo.Info, bool) {
+	info, err := imageinfo.MGImageInfo(mg)
+	if err != nil {
+		log.Warningf("Unable to read image metadata for %v: %v", imageinfo.MGImageRef(mg), err)
+		return info, false
+	}
+	return info, true
+}
+
 func GetBuildEnvVars(mg *metagraf.MetaGraf, mgp metagraf.MGProperties) []corev1.EnvVar {
 	var envs []corev1.EnvVar
 
@@ -348,53 +354,6 @@ func MarshalObject(obj runtime.Object) {
 	}
 }
 
-func GetGlobalConfigMapVolumes(mg *metagraf.MetaGraf, Volumes *[]corev1.Volume, VolumeMounts *[]corev1.VolumeMount) {
-	// Only handle global ConfigMaps
-	for _, v := range mg.Spec.Config {
-		if !v.Global {
-			continue
-		}
-
-		paths := []string{"/etc/pki/ca-trust/extracted/pem", "/etc/pki/ca-trust/extracted/java"}
-		items := []string{"tls-ca-bundle.pem", "cacerts"}
-
-		if strings.ToUpper(v.Type) == "TRUSTED-CA" {
-
-			itms := []corev1.KeyToPath{}
-
-			for _, key := range items {
-				itm := corev1.KeyToPath{
-					Key:  "ca-bundle.crt",
-					Path: key,
-				}
-				itms = append(itms, itm)
-			}
-
-			vol := corev1.Volume{
-				Name: "trusted-ca",
-				VolumeSource: corev1.VolumeSource{
-					ConfigMap: &corev1.ConfigMapVolumeSource{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "trusted-ca",
-						},
-						Items: itms,
-					},
-				},
-			}
-			*Volumes = append(*Volumes, vol)
-
-			for _, path := range paths {
-				volm := corev1.VolumeMount{
-					Name:      "trusted-ca",
-					ReadOnly:  true,
-					MountPath: path,
-				}
-				*VolumeMounts = append(*VolumeMounts, volm)
-			}
-		}
-	}
-}
-
 func labelsFromParams(labels []string) map[string]string {
 	ret := make(map[string]string)
 	for _,s := range labels {
@@ -417,28 +376,3 @@ func Labels(name string, input map[string]string ) map[string]string {
 	}
 	return l
 }
-
-// Builds and returns slice of Kubernetes EnvVars for common values
-// extracted from DownwardAPI.
-func DownwardAPIEnvVars() []corev1.EnvVar {
-	vars := []corev1.EnvVar{}
-
-	// Map for fieldRefs: Name, fieldRef
-	fieldRefs := map[string]string{
-		"POD_NAME":"metadata.name",
-		"POD_NAMESPACE":"metadata.namespace",
-		"NODE_NAME":"spec.nodeName",
-	}
-
-	for k, v := range fieldRefs {
-		ev := corev1.EnvVar{
-			Name: k,
-			ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{
-				FieldPath: v,
-			}},
-		}
-		vars = append(vars, ev)
-	}
-	return vars
-}
-
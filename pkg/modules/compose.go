@@ -0,0 +1,256 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	log "k8s.io/klog"
+)
+
+const ComposeFileName = "docker-compose.yaml"
+
+// A docker compose file. Only the parts mg generates are represented.
+type ComposeFile struct {
+	Version  string                    `json:"version"`
+	Services map[string]ComposeService `json:"services"`
+}
+
+type ComposeService struct {
+	Image       string            `json:"image"`
+	Environment map[string]string `json:"environment,omitempty"`
+	Ports       []string          `json:"ports,omitempty"`
+	Volumes     []string          `json:"volumes,omitempty"`
+	DependsOn   []string          `json:"depends_on,omitempty"`
+}
+
+func NewComposeFile() ComposeFile {
+	return ComposeFile{
+		Version:  "3.2",
+		Services: make(map[string]ComposeService),
+	}
+}
+
+// Adds a service for the component described by mg, with environment
+// resolved from mgp. Configuration files are written below params.ComposeDir
+// and mounted where a Deployment would mount the corresponding ConfigMap.
+// Dependencies are only resolved against the specifications in collection,
+// since depends_on can not reference services outside the compose file.
+func (c *ComposeFile) AddService(mg *metagraf.MetaGraf, mgp metagraf.MGProperties, collection []metagraf.MetaGraf) error {
+	objname := Name(mg)
+
+	svc := ComposeService{
+		Image:       composeImage(mg),
+		Environment: composeEnvironment(mg, mgp),
+	}
+
+	for _, p := range mg.Spec.Ports {
+		svc.Ports = append(svc.Ports, strconv.Itoa(int(p)))
+	}
+	sort.Strings(svc.Ports)
+
+	vols, err := composeConfigVolumes(mg, mgp)
+	if err != nil {
+		return err
+	}
+	svc.Volumes = vols
+
+	for _, r := range mg.Spec.Resources {
+		if r.External {
+			continue
+		}
+		dep, ok := composeDependency(r, collection)
+		if !ok {
+			log.Warningf("%v: resource %v not found in collection, skipping depends_on", objname, r.Name)
+			continue
+		}
+		svc.DependsOn = append(svc.DependsOn, dep)
+	}
+
+	c.Services[objname] = svc
+	return nil
+}
+
+// Writes the compose file to params.ComposeDir.
+func (c *ComposeFile) Write() error {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if Output {
+		fmt.Println(string(b))
+	}
+	if Dryrun {
+		return nil
+	}
+
+	file := filepath.Join(params.ComposeDir, ComposeFileName)
+	err = ioutil.WriteFile(file, b, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Wrote compose file: ", file)
+	return nil
+}
+
+// Use spec.image if provided, otherwise the image reference we use in Deployments.
+func composeImage(mg *metagraf.MetaGraf) string {
+	if len(mg.Spec.Image) > 0 {
+		return pinDigest(mg.Spec.Image)
+	}
+	return pinDigest(imageRef(mg))
+}
+
+// Only literal values are exported. Values from Secrets or ConfigMaps in a
+// cluster are not available locally.
+func composeEnvironment(mg *metagraf.MetaGraf, mgp metagraf.MGProperties) map[string]string {
+	env := make(map[string]string)
+	for _, e := range GetEnvVars(mg, mgp) {
+		if e.ValueFrom != nil {
+			log.Warningf("%v: environment variable %v references a cluster resource, skipping", Name(mg), e.Name)
+			continue
+		}
+		env[e.Name] = e.Value
+	}
+	return env
+}
+
+// Writes the data of each ConfigMap mounted by volumes() to files, one
+// file per key, and returns volumes mounting them at the same paths and
+// with the same items and sub paths as in a Deployment. File modes are
+// not applied since bind mounts keep the ownership of the host.
+func composeConfigVolumes(mg *metagraf.MetaGraf, mgp metagraf.MGProperties) ([]string, error) {
+	var vols []string
+	objname := Name(mg)
+
+	for _, m := range FindMetagrafConfigMaps(mg) {
+		if m.Type != MountConfig {
+			continue
+		}
+
+		var dir string
+		var data map[string]string
+		if m.Name == runtimeConfigMapName(mg) {
+			cm, _ := genRuntimeConfigMap(mg, mgp)
+			dir = filepath.Join(objname, "config", "runtime")
+			data = cm.Data
+		} else {
+			conf, ok := composeConfig(mg, m)
+			if !ok {
+				continue
+			}
+			if conf.Global {
+				log.Warningf("%v: global config %v is not available locally, skipping", objname, conf.Name)
+				continue
+			}
+			dir = filepath.Join(objname, "config", strings.ToLower(conf.Name))
+			data = composeConfigData(conf, mgp)
+		}
+
+		src := "./" + filepath.ToSlash(dir)
+		if len(m.SubPath) > 0 {
+			src += "/" + m.SubPath
+		}
+		vols = append(vols, src+":"+m.MountPath+":ro")
+
+		if Dryrun {
+			continue
+		}
+		err := writeComposeConfig(filepath.Join(params.ComposeDir, dir), data, m.Items)
+		if err != nil {
+			return vols, err
+		}
+	}
+	return vols, nil
+}
+
+// Finds the config section a ConfigMap mount was generated from.
+func composeConfig(mg *metagraf.MetaGraf, m Mount) (metagraf.Config, bool) {
+	for _, conf := range mg.Spec.Config {
+		if "cm-"+volumeName(strings.ToLower(conf.Name)) == m.Volume {
+			return conf, true
+		}
+	}
+	return metagraf.Config{}, false
+}
+
+func composeConfigData(conf metagraf.Config, mgp metagraf.MGProperties) map[string]string {
+	data := make(map[string]string)
+	for _, o := range conf.Options {
+		prop := mgp[conf.Name+"|"+o.Name]
+		value := prop.Value
+		if len(value) == 0 {
+			value = o.Default
+		}
+		data[o.Name] = value
+	}
+	return data
+}
+
+// Writes data to dir, only the keys in items at their paths when given.
+func writeComposeConfig(dir string, data map[string]string, items []corev1.KeyToPath) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]string)
+	if len(items) > 0 {
+		for _, i := range items {
+			if v, ok := data[i.Key]; ok {
+				files[i.Path] = v
+			}
+		}
+	} else {
+		files = data
+	}
+
+	for p, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(p))
+		err = os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(file, []byte(content), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find the service name for a resource among the specifications in the collection.
+func composeDependency(r metagraf.Resource, collection []metagraf.MetaGraf) (string, bool) {
+	rname := strings.ToLower(r.Name)
+	for i := range collection {
+		dep := &collection[i]
+		if strings.ToLower(dep.Metadata.Name) == rname || Name(dep) == rname {
+			return Name(dep), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,125 @@
+package modules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestComposeAddService(t *testing.T) {
+	params.ComposeDir = t.TempDir()
+
+	api := metagraf.MetaGraf{}
+	api.Metadata.Name = "api"
+	api.Spec.Version = "1.0.0"
+	api.Spec.Ports = map[string]int32{"http": 8080}
+	api.Spec.Config = []metagraf.Config{
+		{
+			Name:    "app.properties",
+			Type:    "parameters",
+			Options: []metagraf.ConfigParam{{Name: "db.url", Default: "jdbc:default"}},
+		},
+	}
+	api.Spec.Resources = []metagraf.Resource{
+		{Name: "db"},
+		{Name: "payments", External: true},
+	}
+
+	db := metagraf.MetaGraf{}
+	db.Metadata.Name = "db"
+	db.Spec.Version = "2.1.0"
+	db.Spec.Image = "postgres:13"
+
+	mgp := metagraf.MGProperties{}
+	mgp["app.properties|db.url"] = metagraf.MGProperty{Source: "app.properties", Key: "db.url", Value: "jdbc:local"}
+
+	compose := NewComposeFile()
+	collection := []metagraf.MetaGraf{api, db}
+	if err := compose.AddService(&collection[0], mgp, collection); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, ok := compose.Services["apiv1"]
+	if !ok {
+		t.Fatalf("Expected service apiv1, got %v", compose.Services)
+	}
+	if len(svc.DependsOn) != 1 || svc.DependsOn[0] != "dbv2" {
+		t.Errorf("Expected depends_on [dbv2], got %v", svc.DependsOn)
+	}
+	if len(svc.Volumes) != 1 || svc.Volumes[0] != "./apiv1/config/app.properties:/mg/config/app.properties:ro" {
+		t.Errorf("Unexpected volumes %v", svc.Volumes)
+	}
+	if svc.Environment["MG_APP_NAME"] != "apiv1" {
+		t.Errorf("Expected MG_APP_NAME apiv1, got %v", svc.Environment["MG_APP_NAME"])
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(params.ComposeDir, "apiv1", "config", "app.properties", "db.url"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "jdbc:local" {
+		t.Errorf("Expected config value jdbc:local, got %v", string(b))
+	}
+}
+
+// Compose mounts configs at the paths volumes() uses.
+func TestComposeConfigMounts(t *testing.T) {
+	params.ComposeDir = t.TempDir()
+
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Metadata.Annotations = map[string]string{RuntimeAnnotation: "liberty"}
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Config = []metagraf.Config{
+		{
+			Name:      "tls",
+			Type:      "parameters",
+			MountPath: "/opt/app/tls",
+			Items:     []corev1.KeyToPath{{Key: "ca", Path: "certs/ca.pem"}},
+			Options:   []metagraf.ConfigParam{{Name: "ca", Default: "pem"}, {Name: "unused", Default: "x"}},
+		},
+		{
+			Name:    "logging",
+			Type:    "parameters",
+			SubPath: "log.properties",
+			Options: []metagraf.ConfigParam{{Name: "log.properties", Default: "level=INFO"}},
+		},
+		{Name: "server", Type: RuntimeConfigType, Options: []metagraf.ConfigParam{{Name: "HTTP_PORT", Default: "9080"}}},
+	}
+
+	compose := NewComposeFile()
+	if err := compose.AddService(&mg, mg.GetProperties(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"./appv1/config/tls:/opt/app/tls:ro",
+		"./appv1/config/logging/log.properties:/mg/config/logging/log.properties:ro",
+		"./appv1/config/runtime/server.env:/config/server.env:ro",
+	}
+	vols := compose.Services["appv1"].Volumes
+	if len(vols) != len(want) {
+		t.Fatalf("Expected volumes %v, got %v", want, vols)
+	}
+	for i := range want {
+		if vols[i] != want[i] {
+			t.Errorf("Expected volume %v, got %v", want[i], vols[i])
+		}
+	}
+
+	dir := filepath.Join(params.ComposeDir, "appv1", "config")
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "tls", "certs", "ca.pem")); err != nil || string(b) != "pem" {
+		t.Errorf("Expected item ca at certs/ca.pem, got %v %v", string(b), err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tls", "unused")); err == nil {
+		t.Errorf("Expected only the keys in items to be written")
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "runtime", "server.env")); err != nil || string(b) != "HTTP_PORT=9080\n" {
+		t.Errorf("Unexpected server.env %v %v", string(b), err)
+	}
+}
@@ -33,22 +33,22 @@ import (
 
 /*
 	This function will inspect the metaGraf specification
-	for which configmaps it will need to create and their
-	type in a map.
+	for which configmaps it will need to mount and where.
 */
-func FindMetagrafConfigMaps(mg *metagraf.MetaGraf) map[string]string {
-	maps := make(map[string]string)
+func FindMetagrafConfigMaps(mg *metagraf.MetaGraf) []Mount {
+	var mounts []Mount
+	objname := Name(mg)
 
 	for _, c := range mg.Spec.Config {
 		// Skip envRef configmaps
 		if strings.ToLower(c.Type) == "envref" {
 			continue
 		}
-		if strings.ToUpper(c.Type) == "JVM_SYS_PROP" {
+		if strings.ToUpper(c.Type) == "JVM_SYS_PROP" || strings.ToUpper(c.Type) == RuntimeConfigType {
 			continue
 		}
 
-		if strings.ToUpper(c.Type) == "TRUSTED-CA" {
+		if isGlobalConfigType(c.Type) {
 			continue
 		}
 
@@ -56,12 +56,36 @@ func FindMetagrafConfigMaps(mg *metagraf.MetaGraf) map[string]string {
 			fmt.Println("The Config type \"cert\" is deprecated!")
 			os.Exit(1)
 		}
-		maps[strings.ToLower(c.Name)] = "config"
+
+		n := strings.ToLower(c.Name)
+		m := Mount{
+			Name:      objname + "-" + volumeName(n),
+			Type:      MountConfig,
+			Volume:    "cm-" + volumeName(n),
+			MountPath: "/mg/" + MountConfig + "/" + n,
+			Mode:      defaultMountMode,
+		}
+		m.apply(c.MountPath, c.SubPath, c.Items, c.Mode)
+		mounts = appendMount(mounts, m)
+	}
+
+	for _, m := range runtimeMounts(mg) {
+		mounts = appendMount(mounts, m)
 	}
 
 	for _, r := range mg.Spec.Resources {
-		if r.Type == "jdbc:oracle:thin" {
-			maps[strings.ToLower(r.User)] = "resource"
+		// Rendered templates exported as env are not mounted. Others
+		// are mounted as single files.
+		if len(r.Template) > 0 && len(r.EnvRef) == 0 {
+			n := resourceConfigKey(&r)
+			mounts = appendMount(mounts, Mount{
+				Name:      resourceConfigMapName(mg, &r),
+				Type:      MountResource,
+				Volume:    "cm-" + volumeName(n),
+				MountPath: "/mg/" + MountResource + "/" + n,
+				SubPath:   n,
+				Mode:      defaultMountMode,
+			})
 		}
 
 		if len(r.TemplateRef) > 0 {
@@ -70,13 +94,19 @@ func FindMetagrafConfigMaps(mg *metagraf.MetaGraf) map[string]string {
 				log.Error(err)
 				os.Exit(-1)
 			}
-			maps[cm.Name] = "template"
+			mounts = appendMount(mounts, Mount{
+				Name:      volumeName(cm.Name),
+				Type:      MountTemplate,
+				Volume:    "cm-" + volumeName(cm.Name),
+				MountPath: "/mg/" + MountTemplate + "/" + cm.Name,
+				Mode:      defaultMountMode,
+			})
 		}
 	}
 
-	log.V(2).Info("FindMetagrafConfigMaps(): Found", len(maps), " ConfigMaps to mount...")
+	log.V(2).Info("FindMetagrafConfigMaps(): Found", len(mounts), " ConfigMaps to mount...")
 
-	return maps
+	return mounts
 }
 
 /*
@@ -117,7 +147,8 @@ func GenConfigMaps(mg *metagraf.MetaGraf) {
 		}
 		genConfigMapsFromConfig(&c, mg)
 	}
-	//genConfigMapsFromResources(mg)
+	genConfigMapsFromResources(mg)
+	genRuntimeConfigMaps(mg)
 }
 
 /*
@@ -183,28 +214,6 @@ func genConfigMapsFromConfig(conf *metagraf.Config, mg *metagraf.MetaGraf) {
 
 }
 
-/*
-	todo: redo this to use template in spec to do this.
-*/
-func genConfigMapsFromResources(mg *metagraf.MetaGraf) {
-	return
-	// objname := Name(mg)
-
-	/*
-		for _, r := range mg.Spec.Resources {
-			if strings.Contains(r.Type, "oracle") {
-				cm := genJDBCOracle(objname, &r)
-				if !Dryrun {
-					StoreConfigMap(cm)
-				}
-				if Output {
-					MarshalObject(cm.DeepCopyObject())
-				}
-			}
-		}
-	*/
-}
-
 func StoreConfigMap(m corev1.ConfigMap) {
 	cmclient := k8sclient.GetCoreClient().ConfigMaps(NameSpace)
 	cm, _ := cmclient.Get(context.TODO(), m.Name, metav1.GetOptions{})
@@ -243,6 +252,16 @@ func DeleteConfigMaps(mg *metagraf.MetaGraf) {
 		name = strings.Replace(name, ".", "-", -1)
 		DeleteConfigMap(name)
 	}
+
+	for i := range mg.Spec.Resources {
+		if len(mg.Spec.Resources[i].Template) > 0 {
+			DeleteConfigMap(resourceConfigMapName(mg, &mg.Spec.Resources[i]))
+		}
+	}
+
+	if len(runtimeFiles(mg, Variables)) > 0 {
+		DeleteConfigMap(runtimeConfigMapName(mg))
+	}
 }
 
 func DeleteConfigMap(name string) {
@@ -18,17 +18,12 @@ package modules
 
 import (
 	"context"
-	"strconv"
-	"strings"
 
 	"github.com/golang/glog"
-	"github.com/laetho/metagraf/internal/pkg/affinity"
-	"github.com/laetho/metagraf/internal/pkg/helpers"
 	"github.com/laetho/metagraf/internal/pkg/k8sclient"
 	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/laetho/metagraf/pkg/metagraf"
 	"github.com/spf13/viper"
-	"k8s.io/apimachinery/pkg/util/intstr"
 
 	//corev1 "k8s.io/api/core/v1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -65,17 +60,12 @@ func GenDeployment(mg *metagraf.MetaGraf, namespace string) {
 
 	var RevisionHistoryLimit int32 = 5
 
-	var MaxSurge intstr.IntOrString
-	MaxSurge.StrVal = "25%"
-	MaxSurge.Type = 1
-	var MaxUnavailable intstr.IntOrString
-	MaxUnavailable.StrVal = "25%"
-	MaxUnavailable.Type = 1
+	MaxSurge, MaxUnavailable := rolloutRollingParams(mg)
 
 	// Instance of RollingDeploymentStrategyParams
 	rollingParams := appsv1.RollingUpdateDeployment{
-		MaxSurge:       &MaxSurge,
-		MaxUnavailable: &MaxUnavailable,
+		MaxSurge:       MaxSurge,
+		MaxUnavailable: MaxUnavailable,
 	}
 
 	// Containers
@@ -87,45 +77,23 @@ func GenDeployment(mg *metagraf.MetaGraf, namespace string) {
 	// Environment
 	var EnvVars []corev1.EnvVar
 
-	// ImageInfo := helpers.SkopeoImageInfo(DockerImage)
-	HasImageInfo := false
-	ImageInfo, err := helpers.ImageInfo(mg)
-	if err != nil {
-		HasImageInfo = false
-	} else {
-		HasImageInfo = true
-	}
+	ImageInfo, HasImageInfo := mgImageInfo(mg)
 
 	EnvVars = GetEnvVars(mg, Variables)
-	if params.DownwardAPIEnvVars {
-		EnvVars = append(EnvVars, DownwardAPIEnvVars()...)
-	}
+	EnvVars = append(EnvVars, GetDownwardAPIEnvVars(mg)...)
 
 	// Environment Variables from baserunimage
 	if BaseEnvs && HasImageInfo {
-		for _, e := range ImageInfo.Config.Env {
-			es := strings.Split(e, "=")
-			if helpers.SliceInString(EnvBlacklistFilter, strings.ToLower(es[0])) {
-				continue
-			}
-			EnvVars = append(EnvVars, corev1.EnvVar{Name: es[0], Value: es[1]})
-		}
+		EnvVars = append(EnvVars, ImageInfo.EnvVars(EnvBlacklistFilter)...)
 	}
 
 	// ContainerPorts
 	if HasImageInfo {
-		for k := range ImageInfo.Config.ExposedPorts {
-			ss := strings.Split(k, "/")
-			port, _ := strconv.Atoi(ss[0])
-			ContainerPort := corev1.ContainerPort{
-				ContainerPort: int32(port),
-				Protocol:      corev1.Protocol(strings.ToUpper(ss[1])),
-			}
-			ContainerPorts = append(ContainerPorts, ContainerPort)
-		}
+		ContainerPorts = ImageInfo.ContainerPorts()
 
 		Volumes, VolumeMounts = volumes(mg, ImageInfo)
 	}
+	Volumes, VolumeMounts = appendDownwardAPIVolume(mg, Volumes, VolumeMounts)
 	// Tying Container PodSpec together
 	Container := corev1.Container{
 		Name:            objname,
@@ -146,6 +114,7 @@ func GenDeployment(mg *metagraf.MetaGraf, namespace string) {
 	if mg.Spec.StartupProbe != probe {
 		Container.StartupProbe = &mg.Spec.StartupProbe
 	}
+	applyRuntimeProbes(mg, &Container)
 	Containers = append(Containers, Container)
 
 	// Tying the DeploymentObject together, literally :)
@@ -171,6 +140,7 @@ func GenDeployment(mg *metagraf.MetaGraf, namespace string) {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:   objname,
 					Labels: l,
+					Annotations: podAnnotations(mg),
 					Namespace: namespace,
 				},
 				Spec: corev1.PodSpec{
@@ -182,8 +152,20 @@ func GenDeployment(mg *metagraf.MetaGraf, namespace string) {
 		Status: appsv1.DeploymentStatus{},
 	}
 
-	if params.WithAffinityRules {
-		obj.Spec.Template.Spec.Affinity = affinity.SoftPodAntiAffinity(objname, params.PodAntiAffinityTopologyKey, params.PodAntiAffinityWeight)
+	applyScheduling(mg, &obj.Spec.Template.Spec)
+	obj.Annotations = pinPodSpecDigests(&obj.Spec.Template.Spec, obj.Annotations)
+
+	if rolloutStrategy(mg) == RolloutRecreate {
+		obj.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+	// Canary and blue-green rollouts replace the Deployment with a Rollout.
+	// An existing Deployment is deleted once the Rollout is stored.
+	if IsProgressiveRollout(mg) {
+		GenRollout(mg, obj.ObjectMeta, *obj.Spec.Replicas, sm, obj.Spec.Template)
+		if !Dryrun {
+			deleteReplacedDeployment(obj.Name)
+		}
+		return
 	}
 
 	if !Dryrun {
@@ -20,13 +20,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strconv"
-	"strings"
 
-	"github.com/laetho/metagraf/internal/pkg/helpers"
+	"github.com/laetho/metagraf/internal/pkg/imageinfo"
 	"github.com/laetho/metagraf/internal/pkg/k8sclient"
 	"github.com/laetho/metagraf/internal/pkg/params"
-	"github.com/openshift/api/image/docker10"
 	"github.com/spf13/viper"
 	log "k8s.io/klog"
 
@@ -35,7 +32,6 @@ import (
 	appsv1 "github.com/openshift/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Todo: Still needs to be split up, but some refactoring has been done.
@@ -63,17 +59,12 @@ func GenDeploymentConfig(mg *metagraf.MetaGraf) {
 	var UpdatePeriodSeconds int64 = 1
 	var IntervalSeconds int64 = 1
 
-	var MaxSurge intstr.IntOrString
-	MaxSurge.StrVal = "25%"
-	MaxSurge.Type = 1
-	var MaxUnavailable intstr.IntOrString
-	MaxUnavailable.StrVal = "25%"
-	MaxUnavailable.Type = 1
+	MaxSurge, MaxUnavailable := rolloutRollingParams(mg)
 
 	// Instance of RollingDeploymentStrategyParams
 	rollingParams := appsv1.RollingDeploymentStrategyParams{
-		MaxSurge:            &MaxSurge,
-		MaxUnavailable:      &MaxUnavailable,
+		MaxSurge:            MaxSurge,
+		MaxUnavailable:      MaxUnavailable,
 		TimeoutSeconds:      &TimeoutSeconds,
 		IntervalSeconds:     &IntervalSeconds,
 		UpdatePeriodSeconds: &UpdatePeriodSeconds,
@@ -87,56 +78,21 @@ func GenDeploymentConfig(mg *metagraf.MetaGraf) {
 	var VolumeMounts []corev1.VolumeMount
 	var EnvVars []corev1.EnvVar
 
-	// ImageInfo := helpers.SkopeoImageInfo(DockerImage)
-	HasImageInfo := false
-	ImageInfo, err := helpers.ImageInfo(mg)
-	if err != nil {
-		HasImageInfo = false
-	} else {
-		HasImageInfo = true
-	}
+	ImageInfo, HasImageInfo := mgImageInfo(mg)
 
 	EnvVars = GetEnvVars(mg, Variables)
-	if params.DownwardAPIEnvVars {
-		EnvVars = append(EnvVars, DownwardAPIEnvVars()...)
-	}
+	EnvVars = append(EnvVars, GetDownwardAPIEnvVars(mg)...)
 	// Environment Variables from baserunimage
 	if BaseEnvs && HasImageInfo {
-		for _, e := range ImageInfo.Config.Env {
-			es := strings.Split(e, "=")
-			if helpers.SliceInString(EnvBlacklistFilter, strings.ToLower(es[0])) {
-				continue
-			}
-			EnvVars = append(EnvVars, corev1.EnvVar{Name: es[0], Value: es[1]})
-		}
+		EnvVars = append(EnvVars, ImageInfo.EnvVars(EnvBlacklistFilter)...)
 	}
 
-	/* Norsk Tipping Specific Logic regarding
-	   WLP / OpenLiberty Features. Should maybe
-	   look at some plugin approach to this later.
-	   todo: Add annotations from metagraf to deployment and expose them to pod using downward api.
-	   info: https://kubernetes.io/docs/tasks/inject-data-application/downward-api-volume-expose-pod-information/#the-downward-api
-		or fieldRef in env.
-	*/
-	if len(mg.Metadata.Annotations["norsk-tipping.no/libertyfeatures"]) > 0 {
-		EnvVars = append(EnvVars, corev1.EnvVar{
-			Name:  "LIBERTY_FEATURES",
-			Value: mg.Metadata.Annotations["norsk-tipping.no/libertyfeatures"],
-		})
-	}
 	// ContainerPorts
 	if HasImageInfo {
-		for k := range ImageInfo.Config.ExposedPorts {
-			ss := strings.Split(k, "/")
-			port, _ := strconv.Atoi(ss[0])
-			ContainerPort := corev1.ContainerPort{
-				ContainerPort: int32(port),
-				Protocol:      corev1.Protocol(strings.ToUpper(ss[1])),
-			}
-			ContainerPorts = append(ContainerPorts, ContainerPort)
-		}
+		ContainerPorts = ImageInfo.ContainerPorts()
 		Volumes, VolumeMounts = volumes(mg, ImageInfo)
 	}
+	Volumes, VolumeMounts = appendDownwardAPIVolume(mg, Volumes, VolumeMounts)
 
 	// Tying Container PodSpec together
 	Container := corev1.Container{
@@ -159,6 +115,7 @@ func GenDeploymentConfig(mg *metagraf.MetaGraf) {
 	if mg.Spec.StartupProbe != probe {
 		Container.StartupProbe = &mg.Spec.StartupProbe
 	}
+	applyRuntimeProbes(mg, &Container)
 	Containers = append(Containers, Container)
 
 	// Tying the DeploymentObject together, literally :)
@@ -182,8 +139,9 @@ func GenDeploymentConfig(mg *metagraf.MetaGraf) {
 			},
 			Template: &corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:   objname,
-					Labels: l,
+					Name:        objname,
+					Labels:      l,
+					Annotations: podAnnotations(mg),
 				},
 				Spec: corev1.PodSpec{
 					Containers: Containers,
@@ -193,6 +151,22 @@ func GenDeploymentConfig(mg *metagraf.MetaGraf) {
 		},
 		Status: appsv1.DeploymentConfigStatus{},
 	}
+	applyScheduling(mg, &obj.Spec.Template.Spec)
+	obj.Annotations = pinPodSpecDigests(&obj.Spec.Template.Spec, obj.Annotations)
+
+	if rolloutStrategy(mg) == RolloutRecreate {
+		obj.Spec.Strategy.Type = appsv1.DeploymentStrategyTypeRecreate
+		obj.Spec.Strategy.RollingParams = nil
+	}
+	// Canary and blue-green rollouts replace the DeploymentConfig with a Rollout.
+	// An existing DeploymentConfig is deleted once the Rollout is stored.
+	if IsProgressiveRollout(mg) {
+		GenRollout(mg, obj.ObjectMeta, obj.Spec.Replicas, obj.Spec.Selector, *obj.Spec.Template)
+		if !Dryrun {
+			deleteReplacedDeploymentConfig(obj.Name)
+		}
+		return
+	}
 
 	if !Dryrun {
 		StoreDeploymentConfig(obj)
@@ -227,92 +201,19 @@ func imageRef(mg *metagraf.MetaGraf) string {
 Builds up slices of corev1.Volume and corev1.VolumeMount structs and returns them.
 Should maybe consider splitting this up even further.
 */
-func volumes(mg *metagraf.MetaGraf, ImageInfo *docker10.DockerImage) ([]corev1.Volume, []corev1.VolumeMount) {
+func volumes(mg *metagraf.MetaGraf, ImageInfo imageinfo.Info) ([]corev1.Volume, []corev1.VolumeMount) {
 	objname := Name(mg)
-	var Volumes []corev1.Volume
-	var VolumeMounts []corev1.VolumeMount
 
 	// Volumes & VolumeMounts from base image into podspec
-	log.V(2).Info("ImageInfo: Got ", len(ImageInfo.Config.Volumes), " volumes from base image...")
-	for k := range ImageInfo.Config.Volumes {
-		// Volume Definitions
-		Volume := corev1.Volume{
-			Name: objname + helpers.PathToIdentifier(k),
-			VolumeSource: corev1.VolumeSource{
-				EmptyDir: &corev1.EmptyDirVolumeSource{},
-			},
-		}
-		Volumes = append(Volumes, Volume)
-
-		VolumeMount := corev1.VolumeMount{
-			MountPath: k,
-			Name:      objname + helpers.PathToIdentifier(k),
-		}
-		VolumeMounts = append(VolumeMounts, VolumeMount)
-	}
-
-	// Put ConfigMap volumes and mounts into PodSpec
-	for n, t := range FindMetagrafConfigMaps(mg) {
-		var mode int32 = 420
-		var vname string
-		var oname string
-
-		vname = "cm-" + strings.Replace(n, ".", "-", -1)
-
-		log.V(2).Infof("Name,Type: %v,%v", n, t)
-
-		if t == "template" {
-			oname = strings.Replace(n, ".", "-", -1)
-		} else {
-			oname = objname + "-" + strings.Replace(n, ".", "-", -1)
-		}
-
-		vol := corev1.Volume{
-			Name: vname,
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: oname,
-					},
-					DefaultMode: &mode,
-				},
-			},
-		}
-
-		volm := corev1.VolumeMount{}
-		volm.Name = vname
-
-		// Special handling of resource because of old hackish handling of oracle jdbc connections
-		if t == "resource" {
-			volm.MountPath = "/mg/" + n
-		} else {
-			volm.MountPath = "/mg/" + t + "/" + n
-		}
-
-		Volumes = append(Volumes, vol)
-		VolumeMounts = append(VolumeMounts, volm)
-	}
-
-	for n, t := range FindSecrets(mg) {
-		log.V(2).Infof("Secret: %v,%v", n, t)
-		voln := strings.Replace(n, ".", "-", -1)
-		var mode int32 = 420
-		vol := corev1.Volume{
-			Name: voln,
-			VolumeSource: corev1.VolumeSource{
-				Secret: &corev1.SecretVolumeSource{
-					SecretName:  n,
-					DefaultMode: &mode,
-				},
-			},
-		}
-
-		volm := corev1.VolumeMount{
-			Name:      voln,
-			MountPath: "/mg/secret/" + n,
-		}
-		Volumes = append(Volumes, vol)
-		VolumeMounts = append(VolumeMounts, volm)
+	log.V(2).Info("ImageInfo: Got ", len(ImageInfo.Volumes), " volumes from base image...")
+	Volumes := ImageInfo.ImageVolumes(objname)
+	VolumeMounts := ImageInfo.ImageVolumeMounts(objname)
+
+	// Put ConfigMap and Secret volumes and mounts into PodSpec
+	for _, m := range append(FindMetagrafConfigMaps(mg), FindSecrets(mg)...) {
+		log.V(2).Infof("Mount %v %v at %v", m.Type, m.Name, m.MountPath)
+		Volumes = append(Volumes, m.ToVolume())
+		VolumeMounts = append(VolumeMounts, m.ToVolumeMount())
 	}
 
 	GetGlobalConfigMapVolumes(mg, &Volumes, &VolumeMounts)
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"os"
+
+	"github.com/laetho/metagraf/internal/pkg/imageinfo"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	corev1 "k8s.io/api/core/v1"
+	log "k8s.io/klog"
+)
+
+// Prefix of annotations recording the image reference a container
+// image was pinned from. The container name is appended.
+const OriginalImageAnnotationPrefix = "original-image.metagraf.io/"
+
+// Resolves imageref to a digest reference when params.PinDigests is set.
+// Exits if the image can not be resolved, rendering a manifest with a
+// floating tag would defeat the purpose.
+func pinDigest(imageref string) string {
+	if !params.PinDigests {
+		return imageref
+	}
+	pinned, err := imageinfo.NewRegistryProvider().Digest(imageref)
+	if err != nil {
+		log.Errorf("Unable to pin %v to a digest: %v", imageref, err)
+		os.Exit(1)
+	}
+	log.V(2).Infof("Pinned %v to %v", imageref, pinned)
+	return pinned
+}
+
+// Pins the images of all containers in spec when params.PinDigests is set.
+// Returns annotations with the original references added.
+func pinPodSpecDigests(spec *corev1.PodSpec, annotations map[string]string) map[string]string {
+	if !params.PinDigests {
+		return annotations
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	pin := func(c *corev1.Container) {
+		pinned := pinDigest(c.Image)
+		if pinned != c.Image {
+			annotations[OriginalImageAnnotationPrefix+c.Name] = c.Image
+			c.Image = pinned
+		}
+	}
+	for i := range spec.InitContainers {
+		pin(&spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		pin(&spec.Containers[i])
+	}
+	return annotations
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	log "k8s.io/klog"
+)
+
+// Annotations overriding --downward-api-envvars and --downward-api-volume
+// per specification. The env vars annotation takes true, false or a comma
+// separated list of environment variable names. The volume annotation
+// takes true, false or the directory to mount the volume in.
+const (
+	DownwardAPIEnvVarsAnnotation = "metagraf.io/downward-api-envvars"
+	DownwardAPIVolumeAnnotation  = "metagraf.io/downward-api-volume"
+)
+
+// Default directory of the downward API volume.
+const DownwardAPIVolumePath = "/etc/podinfo"
+
+type downwardAPIEnvVar struct {
+	Name     string
+	Field    string
+	Resource string
+}
+
+// The opinionated selection of downward API environment variables.
+var downwardAPIEnvVars = []downwardAPIEnvVar{
+	{Name: "POD_NAME", Field: "metadata.name"},
+	{Name: "POD_NAMESPACE", Field: "metadata.namespace"},
+	{Name: "POD_IP", Field: "status.podIP"},
+	{Name: "NODE_NAME", Field: "spec.nodeName"},
+	{Name: "SERVICE_ACCOUNT", Field: "spec.serviceAccountName"},
+	{Name: "CPU_REQUEST", Resource: "requests.cpu"},
+	{Name: "CPU_LIMIT", Resource: "limits.cpu"},
+	{Name: "MEMORY_REQUEST", Resource: "requests.memory"},
+	{Name: "MEMORY_LIMIT", Resource: "limits.memory"},
+}
+
+func (d downwardAPIEnvVar) EnvVar() corev1.EnvVar {
+	if len(d.Resource) > 0 {
+		return corev1.EnvVar{
+			Name: d.Name,
+			ValueFrom: &corev1.EnvVarSource{ResourceFieldRef: &corev1.ResourceFieldSelector{
+				Resource: d.Resource,
+			}},
+		}
+	}
+	return corev1.EnvVar{
+		Name: d.Name,
+		ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{
+			FieldPath: d.Field,
+		}},
+	}
+}
+
+// Builds and returns slice of Kubernetes EnvVars for common values
+// extracted from DownwardAPI.
+func DownwardAPIEnvVars() []corev1.EnvVar {
+	vars := []corev1.EnvVar{}
+	for _, d := range downwardAPIEnvVars {
+		vars = append(vars, d.EnvVar())
+	}
+	return vars
+}
+
+// Downward API environment variables for a component, selected by
+// the downward API env vars annotation or params.DownwardAPIEnvVars.
+func GetDownwardAPIEnvVars(mg *metagraf.MetaGraf) []corev1.EnvVar {
+	value, ok := mg.Metadata.Annotations[DownwardAPIEnvVarsAnnotation]
+	if !ok {
+		if params.DownwardAPIEnvVars {
+			return DownwardAPIEnvVars()
+		}
+		return nil
+	}
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		if enabled {
+			return DownwardAPIEnvVars()
+		}
+		return nil
+	}
+
+	var vars []corev1.EnvVar
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		found := false
+		for _, d := range downwardAPIEnvVars {
+			if d.Name == name {
+				vars = append(vars, d.EnvVar())
+				found = true
+			}
+		}
+		if !found {
+			log.Warningf("Unknown downward API environment variable %v in annotation %v", name, DownwardAPIEnvVarsAnnotation)
+		}
+	}
+	return vars
+}
+
+// Returns the directory to mount the downward API volume in, selected
+// by the downward API volume annotation or params.DownwardAPIVolume.
+func downwardAPIVolumePath(mg *metagraf.MetaGraf) (string, bool) {
+	value, ok := mg.Metadata.Annotations[DownwardAPIVolumeAnnotation]
+	if !ok {
+		return DownwardAPIVolumePath, params.DownwardAPIVolume
+	}
+	if strings.HasPrefix(value, "/") {
+		return value, true
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warningf("Invalid value %v for annotation %v, use true, false or a directory", value, DownwardAPIVolumeAnnotation)
+	}
+	return DownwardAPIVolumePath, enabled
+}
+
+// Appends a volume exposing the labels and annotations of the pod as
+// files, and its mount, when enabled for the component.
+func appendDownwardAPIVolume(mg *metagraf.MetaGraf, vols []corev1.Volume, mounts []corev1.VolumeMount) ([]corev1.Volume, []corev1.VolumeMount) {
+	path, enabled := downwardAPIVolumePath(mg)
+	if !enabled {
+		return vols, mounts
+	}
+
+	vols = append(vols, corev1.Volume{
+		Name: "podinfo",
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				Items: []corev1.DownwardAPIVolumeFile{
+					{Path: "labels", FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels"}},
+					{Path: "annotations", FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations"}},
+				},
+			},
+		},
+	})
+	mounts = append(mounts, corev1.VolumeMount{
+		Name:      "podinfo",
+		ReadOnly:  true,
+		MountPath: path,
+	})
+	return vols, mounts
+}
+
+// Annotations of the pod template, the metadata annotations of the
+// specification.
+func podAnnotations(mg *metagraf.MetaGraf) map[string]string {
+	if len(mg.Metadata.Annotations) == 0 {
+		return nil
+	}
+	annotations := make(map[string]string)
+	for k, v := range mg.Metadata.Annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
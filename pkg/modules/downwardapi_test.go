@@ -0,0 +1,46 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+)
+
+func TestDownwardAPI(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+
+	if envs := GetDownwardAPIEnvVars(&mg); len(envs) != 0 {
+		t.Errorf("Expected no downward API env vars by default, got %v", envs)
+	}
+	params.DownwardAPIEnvVars = true
+	defer func() { params.DownwardAPIEnvVars = false }()
+	if envs := GetDownwardAPIEnvVars(&mg); len(envs) != len(downwardAPIEnvVars) {
+		t.Errorf("Expected all downward API env vars with the flag, got %v", envs)
+	}
+
+	mg.Metadata.Annotations = map[string]string{
+		DownwardAPIEnvVarsAnnotation: "pod_ip, MEMORY_LIMIT",
+		DownwardAPIVolumeAnnotation:  "/opt/podinfo",
+	}
+	envs := GetDownwardAPIEnvVars(&mg)
+	if len(envs) != 2 || envs[0].ValueFrom.FieldRef.FieldPath != "status.podIP" || envs[1].ValueFrom.ResourceFieldRef.Resource != "limits.memory" {
+		t.Errorf("Unexpected annotated downward API env vars %v", envs)
+	}
+
+	vols, mounts := appendDownwardAPIVolume(&mg, nil, nil)
+	if len(vols) != 1 || len(vols[0].DownwardAPI.Items) != 2 || mounts[0].MountPath != "/opt/podinfo" {
+		t.Errorf("Unexpected downward API volume %v, mounts %v", vols, mounts)
+	}
+
+	mg.Metadata.Annotations[DownwardAPIVolumeAnnotation] = "false"
+	params.DownwardAPIVolume = true
+	defer func() { params.DownwardAPIVolume = false }()
+	if vols, _ := appendDownwardAPIVolume(&mg, nil, nil); len(vols) != 0 {
+		t.Errorf("Expected the annotation to disable the downward API volume, got %v", vols)
+	}
+
+	if a := podAnnotations(&mg); a[DownwardAPIVolumeAnnotation] != "false" {
+		t.Errorf("Expected pod annotations from the specification, got %v", a)
+	}
+}
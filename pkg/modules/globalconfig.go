@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	log "k8s.io/klog"
+)
+
+// Mount paths under this image family are used when the base image
+// family of a component has no paths of its own.
+const DefaultImageFamily = "default"
+
+// A type of global configuration. A global spec.config entry with the
+// name of the type as its type mounts the shared ConfigMap or Secret,
+// and sets the environment variables of the type, in the component.
+//
+// Types are read from the globalconfigs key in the mg config file:
+//
+//	globalconfigs:
+//	  trusted-ca:
+//	    configmap: trusted-ca
+//	    items:
+//	    - key: ca-bundle.crt
+//	      path: ca-certificates.crt
+//	    mountpaths:
+//	      debian: [/etc/ssl/certs]
+//	      alpine: [/etc/ssl/certs]
+//	    env:
+//	    - name: SSL_CERT_FILE
+//	      value: /etc/ssl/certs/ca-certificates.crt
+type GlobalConfigType struct {
+	// Name of the ConfigMap to mount. Either ConfigMap or Secret is required.
+	ConfigMap string `mapstructure:"configmap"`
+	// Name of the Secret to mount.
+	Secret string `mapstructure:"secret"`
+	// Keys to project into the mount paths, all keys if empty.
+	Items []corev1.KeyToPath `mapstructure:"items"`
+	// Directories to mount the volume in, keyed by base image family.
+	MountPaths map[string][]string `mapstructure:"mountpaths"`
+	Env        []corev1.EnvVar     `mapstructure:"env"`
+}
+
+// Built in global config types, overridden by types with the same name
+// in the config file.
+var defaultGlobalConfigTypes = map[string]GlobalConfigType{
+	"trusted-ca": {
+		ConfigMap: "trusted-ca",
+		Items: []corev1.KeyToPath{
+			{Key: "ca-bundle.crt", Path: "tls-ca-bundle.pem"},
+			{Key: "ca-bundle.crt", Path: "cacerts"},
+		},
+		MountPaths: map[string][]string{
+			DefaultImageFamily: {"/etc/pki/ca-trust/extracted/pem", "/etc/pki/ca-trust/extracted/java"},
+		},
+	},
+}
+
+// Returns the registered global config types keyed by lower case name.
+func GlobalConfigTypes() map[string]GlobalConfigType {
+	types := make(map[string]GlobalConfigType)
+	for n, t := range defaultGlobalConfigTypes {
+		types[n] = t
+	}
+
+	configured := make(map[string]GlobalConfigType)
+	if err := viper.UnmarshalKey("globalconfigs", &configured); err != nil {
+		log.Warningf("Unable to read globalconfigs from config file: %v", err)
+		return types
+	}
+	for n, t := range configured {
+		if len(t.ConfigMap) == 0 && len(t.Secret) == 0 {
+			log.Warningf("Global config type %v has neither configmap nor secret, skipping", n)
+			continue
+		}
+		types[strings.ToLower(n)] = t
+	}
+	return types
+}
+
+// Looks up the global config type named by a spec.config type.
+func globalConfigType(types map[string]GlobalConfigType, name string) (GlobalConfigType, bool) {
+	t, ok := types[strings.ToLower(name)]
+	return t, ok
+}
+
+// Mount paths of the type for the base image family given by
+// params.ImageFamily, falling back to the default family.
+func (t GlobalConfigType) mountPaths() []string {
+	family := strings.ToLower(params.ImageFamily)
+	if len(family) == 0 {
+		family = strings.ToLower(viper.GetString("imagefamily"))
+	}
+	if paths, ok := t.MountPaths[family]; ok {
+		return paths
+	}
+	return t.MountPaths[DefaultImageFamily]
+}
+
+func (t GlobalConfigType) volume(name string) corev1.Volume {
+	vol := corev1.Volume{Name: name}
+	if len(t.Secret) > 0 {
+		vol.VolumeSource.Secret = &corev1.SecretVolumeSource{
+			SecretName: t.Secret,
+			Items:      t.Items,
+		}
+		return vol
+	}
+	vol.VolumeSource.ConfigMap = &corev1.ConfigMapVolumeSource{
+		LocalObjectReference: corev1.LocalObjectReference{Name: t.ConfigMap},
+		Items:                t.Items,
+	}
+	return vol
+}
+
+// Mounts the ConfigMap or Secret of each global spec.config entry with
+// a registered global config type.
+func GetGlobalConfigMapVolumes(mg *metagraf.MetaGraf, Volumes *[]corev1.Volume, VolumeMounts *[]corev1.VolumeMount) {
+	types := GlobalConfigTypes()
+	seen := make(map[string]bool)
+
+	for _, c := range mg.Spec.Config {
+		if !c.Global {
+			continue
+		}
+		t, ok := globalConfigType(types, c.Type)
+		if !ok {
+			continue
+		}
+		name := volumeName(strings.ToLower(c.Type))
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		paths := t.mountPaths()
+		if len(paths) == 0 {
+			log.Warningf("Global config type %v has no mount paths for image family %v", c.Type, params.ImageFamily)
+			continue
+		}
+
+		*Volumes = append(*Volumes, t.volume(name))
+		for _, p := range paths {
+			*VolumeMounts = append(*VolumeMounts, corev1.VolumeMount{
+				Name:      name,
+				ReadOnly:  true,
+				MountPath: p,
+			})
+		}
+	}
+}
+
+// Environment variables of the global config types used by global
+// spec.config entries.
+func globalConfigEnvVars(mg *metagraf.MetaGraf) []corev1.EnvVar {
+	var envs []corev1.EnvVar
+	types := GlobalConfigTypes()
+	seen := make(map[string]bool)
+
+	for _, c := range mg.Spec.Config {
+		if !c.Global {
+			continue
+		}
+		t, ok := globalConfigType(types, c.Type)
+		if !ok || seen[strings.ToLower(c.Type)] {
+			continue
+		}
+		seen[strings.ToLower(c.Type)] = true
+		envs = append(envs, t.Env...)
+	}
+	return envs
+}
+
+// Reports whether a spec.config type names a registered global config type.
+func isGlobalConfigType(name string) bool {
+	_, ok := globalConfigType(GlobalConfigTypes(), name)
+	return ok
+}
@@ -0,0 +1,79 @@
+package modules
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var globalConfigFile = `
+globalconfigs:
+  trusted-ca:
+    configmap: trusted-ca
+    items:
+    - key: ca-bundle.crt
+      path: ca-certificates.crt
+    mountpaths:
+      debian: [/etc/ssl/certs]
+      default: [/etc/pki/ca-trust/extracted/pem]
+    env:
+    - name: SSL_CERT_FILE
+      value: /etc/ssl/certs/ca-certificates.crt
+  logging:
+    secret: shared-logging
+    mountpaths:
+      default: [/etc/logging]
+`
+
+func TestGlobalConfigTypes(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Config = []metagraf.Config{
+		{Name: "ca", Type: "TRUSTED-CA", Global: true},
+		{Name: "logging", Type: "logging", Global: true},
+		{Name: "app.properties", Type: "parameters"},
+	}
+
+	// The built in TRUSTED-CA type is used without a config file.
+	var vols []corev1.Volume
+	var mounts []corev1.VolumeMount
+	GetGlobalConfigMapVolumes(&mg, &vols, &mounts)
+	if len(vols) != 1 || vols[0].ConfigMap.Name != "trusted-ca" || len(vols[0].ConfigMap.Items) != 2 || len(mounts) != 2 {
+		t.Errorf("Unexpected built in TRUSTED-CA volumes %v, mounts %v", vols, mounts)
+	}
+
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(bytes.NewBufferString(globalConfigFile)); err != nil {
+		t.Fatal(err)
+	}
+	defer viper.Reset()
+	params.ImageFamily = "debian"
+	defer func() { params.ImageFamily = "" }()
+
+	vols, mounts = nil, nil
+	GetGlobalConfigMapVolumes(&mg, &vols, &mounts)
+	if len(vols) != 2 {
+		t.Fatalf("Expected 2 global volumes, got %v", vols)
+	}
+	if vols[0].ConfigMap.Items[0].Path != "ca-certificates.crt" || mounts[0].MountPath != "/etc/ssl/certs" {
+		t.Errorf("Unexpected debian TRUSTED-CA volume %v, mount %v", vols[0], mounts[0])
+	}
+	if vols[1].Secret == nil || vols[1].Secret.SecretName != "shared-logging" || mounts[1].MountPath != "/etc/logging" {
+		t.Errorf("Unexpected logging volume %v, mount %v", vols[1], mounts[1])
+	}
+
+	envs := globalConfigEnvVars(&mg)
+	if len(envs) != 1 || envs[0].Name != "SSL_CERT_FILE" {
+		t.Errorf("Unexpected env vars %v", envs)
+	}
+
+	cms := FindMetagrafConfigMaps(&mg)
+	if len(cms) != 1 || cms[0].Name != "appv1-app-properties" {
+		t.Errorf("Expected only app.properties to be mounted, got %v", cms)
+	}
+}
@@ -20,10 +20,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/blang/semver"
+	"github.com/laetho/metagraf/internal/pkg/imageurl"
 	k8sclient "github.com/laetho/metagraf/internal/pkg/k8sclient"
 	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/laetho/metagraf/pkg/metagraf"
+	"github.com/spf13/viper"
 	log "k8s.io/klog"
 
 	imagev1 "github.com/openshift/api/image/v1"
@@ -39,9 +43,6 @@ func GenImageStream(mg *metagraf.MetaGraf, namespace string) {
 	// Resource labels
 	l := Labels(objname, labelsFromParams(params.Labels))
 
-	objref := corev1.ObjectReference{}
-	objref.Kind = ""
-
 	is := imagev1.ImageStream{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ImageStream",
@@ -52,15 +53,7 @@ func GenImageStream(mg *metagraf.MetaGraf, namespace string) {
 			Labels: l,
 		},
 		Spec: imagev1.ImageStreamSpec{
-			Tags: []imagev1.TagReference{
-				{
-					From: &corev1.ObjectReference{
-						Kind: "DockerImage",
-						Name: "docker-registry.default.svc:5000/" + namespace + "/" + objname + ":latest",
-					},
-					Name: "latest",
-				},
-			},
+			Tags: imageStreamTags(mg, namespace),
 		},
 	}
 
@@ -73,6 +66,84 @@ func GenImageStream(mg *metagraf.MetaGraf, namespace string) {
 
 }
 
+// Returns the tags of the ImageStream. A spec.image from an external
+// registry is imported as a single tag. Otherwise there is a latest tag
+// and a tag for each semver level of the version, all pointing at the
+// internal registry.
+func imageStreamTags(mg *metagraf.MetaGraf, namespace string) []imagev1.TagReference {
+	refpolicy := imagev1.TagReferencePolicy{
+		Type: imagev1.TagReferencePolicyType(params.ImageStreamReferencePolicy),
+	}
+	registry := internalRegistry()
+
+	if len(mg.Spec.Image) > 0 && !strings.HasPrefix(mg.Spec.Image, registry+"/") {
+		return []imagev1.TagReference{
+			{
+				Name: imageTag(mg.Spec.Image),
+				From: &corev1.ObjectReference{
+					Kind: "DockerImage",
+					Name: mg.Spec.Image,
+				},
+				ImportPolicy: imagev1.TagImportPolicy{
+					Scheduled: params.ImageStreamScheduledImport,
+					Insecure:  params.ImageStreamInsecureImport,
+				},
+				ReferencePolicy: refpolicy,
+			},
+		}
+	}
+
+	var tags []imagev1.TagReference
+	for _, t := range append([]string{"latest"}, versionTags(mg)...) {
+		tags = append(tags, imagev1.TagReference{
+			Name: t,
+			From: &corev1.ObjectReference{
+				Kind: "DockerImage",
+				Name: registry + "/" + namespace + "/" + Name(mg) + ":" + t,
+			},
+			ReferencePolicy: refpolicy,
+		})
+	}
+	return tags
+}
+
+// Returns a tag for each semver level of the version, 1, 1.2 and 1.2.3.
+// Returns nothing if the version is not semver.
+func versionTags(mg *metagraf.MetaGraf) []string {
+	version := mg.Spec.Version
+	if len(Version) > 0 {
+		version = Version
+	}
+	sv, err := semver.Parse(version)
+	if err != nil {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("%d", sv.Major),
+		fmt.Sprintf("%d.%d", sv.Major, sv.Minor),
+		fmt.Sprintf("%d.%d.%d", sv.Major, sv.Minor, sv.Patch),
+	}
+}
+
+// Returns the tag of an image reference, latest if none.
+func imageTag(ref string) string {
+	_, tag, err := imageurl.SplitTag(ref)
+	if err != nil || len(tag) == 0 {
+		return "latest"
+	}
+	return tag
+}
+
+// Host of the internal registry from the internalregistry config key.
+func internalRegistry() string {
+	registry := viper.GetString("internalregistry")
+	if len(registry) == 0 {
+		return params.InternalRegistryDefault
+	}
+	return registry
+}
+
+// Creates the ImageStream or updates the tags of an existing one.
 func StoreImageStream(obj imagev1.ImageStream) {
 
 	log.V(2).Infof("ResourceVersion: %v Length: %v", obj.ResourceVersion, len(obj.ResourceVersion))
@@ -81,13 +152,16 @@ func StoreImageStream(obj imagev1.ImageStream) {
 	client := k8sclient.GetImageClient().ImageStreams(NameSpace)
 
 	im, err := client.Get(context.TODO(), obj.Name, metav1.GetOptions{})
-	if len(im.ResourceVersion) > 0 {
+	if err == nil && len(im.ResourceVersion) > 0 {
+		im.Labels = obj.Labels
+		im.Spec.Tags = obj.Spec.Tags
+		_, err := client.Update(context.TODO(), im, metav1.UpdateOptions{})
 		if err != nil {
 			log.Error(err)
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		log.V(2).Infof("ImageStream: %v exists, skipping...", obj.Name)
+		fmt.Println("Updated ImageStream:", obj.Name, "in namespace:", NameSpace)
 	} else {
 		_, err := client.Create(context.TODO(), &obj, metav1.CreateOptions{})
 		if err != nil {
@@ -116,3 +190,21 @@ func DeleteImageStream(name string) {
 	}
 	fmt.Println("Deleted ImageStream: ", name, ", in namespace: ", NameSpace)
 }
+
+func DeleteImageStreamTag(name string) {
+	client := k8sclient.GetImageClient().ImageStreamTags(NameSpace)
+
+	_, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		fmt.Println("ImageStreamTag: ", name, "does not exist in namespace: ", NameSpace, ", skipping...")
+		return
+	}
+
+	err = client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		fmt.Println("Unable to delete ImageStreamTag: ", name, " in namespace: ", NameSpace)
+		log.Error(err)
+		return
+	}
+	fmt.Println("Deleted ImageStreamTag: ", name, ", in namespace: ", NameSpace)
+}
@@ -0,0 +1,34 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+)
+
+func TestImageStreamTags(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.2.3"
+
+	tags := imageStreamTags(&mg, "test")
+	expected := []string{"latest", "1", "1.2", "1.2.3"}
+	if len(tags) != len(expected) {
+		t.Fatalf("Expected %v tags, got %v", len(expected), len(tags))
+	}
+	for i, tag := range tags {
+		if tag.Name != expected[i] {
+			t.Errorf("Expected tag %v, got %v", expected[i], tag.Name)
+		}
+		from := "image-registry.openshift-image-registry.svc:5000/test/appv1:" + expected[i]
+		if tag.From.Name != from {
+			t.Errorf("Expected from %v, got %v", from, tag.From.Name)
+		}
+	}
+
+	mg.Spec.Image = "docker.io/library/nginx:1.19"
+	tags = imageStreamTags(&mg, "test")
+	if len(tags) != 1 || tags[0].Name != "1.19" || tags[0].From.Name != mg.Spec.Image {
+		t.Errorf("Expected a single 1.19 tag importing %v, got %v", mg.Spec.Image, tags)
+	}
+}
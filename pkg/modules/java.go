@@ -18,6 +18,7 @@ package modules
 import (
 	"github.com/laetho/metagraf/pkg/metagraf"
 	corev1 "k8s.io/api/core/v1"
+	log "k8s.io/klog"
 	"strings"
 )
 
@@ -54,3 +55,63 @@ func GenEnvVar_JVM_SYS_PROP(mgp metagraf.MGProperties, name string) corev1.EnvVa
 		Value: strings.Join(props, " "),
 	}
 }
+
+// Formats options as -Dkey=value system properties.
+func jvmSysProps(opts []RuntimeOption) string {
+	var props []string
+	for _, o := range opts {
+		props = append(props, "-D"+o.Key+"="+o.Value)
+	}
+	return strings.Join(props, " ")
+}
+
+// Generates an EnvVar for each JVM_SYS_PROP target. Targets are the
+// environment variables of type JVM_SYS_PROP and the Target of
+// JVM_SYS_PROP configs. A target receives the options of configs
+// targeting it, or of configs without a Target if none does.
+func jvmSysPropEnvVars(mg *metagraf.MetaGraf, mgp metagraf.MGProperties) []corev1.EnvVar {
+	configs := GetMetagrafConfigsByType(mg, "JVM_SYS_PROP")
+
+	var targets []string
+	targeted := make(map[string]bool)
+	for _, e := range mg.GetEnvVarByType("JVM_SYS_PROP") {
+		targets = append(targets, e.Name)
+	}
+	for _, c := range configs {
+		if len(c.Target) == 0 {
+			continue
+		}
+		if !targeted[c.Target] && !containsString(targets, c.Target) {
+			targets = append(targets, c.Target)
+		}
+		targeted[c.Target] = true
+	}
+
+	var envs []corev1.EnvVar
+	untargeted := 0
+	for _, t := range targets {
+		var opts []RuntimeOption
+		for i := range configs {
+			if configs[i].Target == t || (len(configs[i].Target) == 0 && !targeted[t]) {
+				opts = append(opts, runtimeOptions(&configs[i], "JVM_SYS_PROP", mgp)...)
+			}
+		}
+		if !targeted[t] {
+			untargeted++
+		}
+		envs = append(envs, corev1.EnvVar{Name: t, Value: jvmSysProps(opts)})
+	}
+	if untargeted > 1 {
+		log.Warningf("%v JVM_SYS_PROP variables receive the JVM_SYS_PROP configs without a target, their options will be duplicated.", untargeted)
+	}
+	return envs
+}
+
+func containsString(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
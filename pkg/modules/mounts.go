@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"path"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Default file mode of mounted Secrets and ConfigMaps, 0644.
+const defaultMountMode int32 = 420
+
+// Mount types.
+const (
+	MountSecret   = "secret"
+	MountConfig   = "config"
+	MountResource = "resource"
+	MountTemplate = "template"
+)
+
+// A Secret or ConfigMap mounted into the pod of a component.
+type Mount struct {
+	// Name of the Secret or ConfigMap.
+	Name string
+	// secret, or the kind of ConfigMap, config, resource or template.
+	Type      string
+	Volume    string
+	MountPath string
+	// Mounts a single file from the volume at MountPath.
+	SubPath string
+	Items   []corev1.KeyToPath
+	Mode    int32
+}
+
+// Applies mount path, sub path, items and mode from the specification,
+// unless params.LegacyMounts. Without a mount path, a sub path is mounted
+// in the conventional directory.
+func (m *Mount) apply(mountpath string, subpath string, items []corev1.KeyToPath, mode *int32) {
+	if params.LegacyMounts {
+		return
+	}
+	if len(subpath) > 0 {
+		m.SubPath = subpath
+		m.MountPath = path.Join(m.MountPath, path.Base(subpath))
+	}
+	if len(mountpath) > 0 {
+		m.MountPath = mountpath
+	}
+	m.Items = items
+	if mode != nil {
+		m.Mode = *mode
+	}
+}
+
+func (m Mount) ToVolume() corev1.Volume {
+	mode := m.Mode
+	if m.Type == MountSecret {
+		return corev1.Volume{
+			Name: m.Volume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  m.Name,
+					Items:       m.Items,
+					DefaultMode: &mode,
+				},
+			},
+		}
+	}
+	return corev1.Volume{
+		Name: m.Volume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: m.Name,
+				},
+				Items:       m.Items,
+				DefaultMode: &mode,
+			},
+		},
+	}
+}
+
+func (m Mount) ToVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      m.Volume,
+		MountPath: m.MountPath,
+		SubPath:   m.SubPath,
+	}
+}
+
+// Appends m unless a mount of the same volume is already present.
+func appendMount(mounts []Mount, m Mount) []Mount {
+	for _, e := range mounts {
+		if e.Volume == m.Volume {
+			return mounts
+		}
+	}
+	return append(mounts, m)
+}
+
+func volumeName(name string) string {
+	return strings.Replace(name, ".", "-", -1)
+}
@@ -0,0 +1,62 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestFindMounts(t *testing.T) {
+	var mode int32 = 256
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Config = []metagraf.Config{
+		{Name: "app.properties", Type: "parameters"},
+		{Name: "logback.xml", Type: "parameters", MountPath: "/opt/app/logback.xml", SubPath: "logback.xml"},
+	}
+	mg.Spec.Secret = []metagraf.Secret{
+		{Name: "keystore", MountPath: "/opt/app/tls", Mode: &mode, Items: []corev1.KeyToPath{{Key: "tls.crt", Path: "cert.pem"}}},
+		{Name: "token", SubPath: "token"},
+		{Name: "Shared-CA", Global: true},
+	}
+
+	configs := FindMetagrafConfigMaps(&mg)
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 ConfigMap mounts, got %v", configs)
+	}
+	if configs[0].Name != "appv1-app-properties" || configs[0].MountPath != "/mg/config/app.properties" || configs[0].SubPath != "" {
+		t.Errorf("Unexpected default mount %+v", configs[0])
+	}
+	if configs[1].MountPath != "/opt/app/logback.xml" || configs[1].SubPath != "logback.xml" {
+		t.Errorf("Unexpected sub path mount %+v", configs[1])
+	}
+
+	secrets := FindSecrets(&mg)
+	if len(secrets) != 3 {
+		t.Fatalf("Expected 3 Secret mounts, got %v", secrets)
+	}
+	vol := secrets[0].ToVolume()
+	if vol.Secret.SecretName != "appv1-keystore" || secrets[2].ToVolume().Secret.SecretName != "shared-ca" {
+		t.Errorf("Expected mounts of the Secrets generated by GenSecrets, got %v and %v", vol.Secret.SecretName, secrets[2].ToVolume().Secret.SecretName)
+	}
+	if secrets[0].MountPath != "/opt/app/tls" || *vol.Secret.DefaultMode != mode || len(vol.Secret.Items) != 1 {
+		t.Errorf("Unexpected secret mount %+v", secrets[0])
+	}
+	if secrets[1].MountPath != "/mg/secret/token/token" || secrets[1].ToVolumeMount().SubPath != "token" {
+		t.Errorf("Unexpected sub path secret mount %+v", secrets[1])
+	}
+
+	params.LegacyMounts = true
+	defer func() { params.LegacyMounts = false }()
+	secrets = FindSecrets(&mg)
+	vol = secrets[0].ToVolume()
+	if secrets[0].MountPath != "/mg/secret/keystore" || *vol.Secret.DefaultMode != defaultMountMode || vol.Secret.Items != nil {
+		t.Errorf("Expected legacy mount, got %+v", secrets[0])
+	}
+	if secrets[0].Name != "keystore" || secrets[2].Name != "shared-ca" {
+		t.Errorf("Expected legacy Secret names, got %v and %v", secrets[0].Name, secrets[2].Name)
+	}
+}
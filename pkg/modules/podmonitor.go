@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	log "k8s.io/klog"
+)
+
+// Generates a PodMonitor for components that are not exposed through a Service.
+// Uses the same port and path conventions as GenServiceMonitor.
+func GenPodMonitor(mg *metagraf.MetaGraf) {
+	objname := Name(mg)
+
+	// Resource labels
+	l := Labels(objname, labelsFromParams(params.Labels))
+	l["app.kubernetes.io/instance"] = objname
+	l["prometheus"] = params.ServiceMonitorOperatorName
+
+	// Selector
+	s := make(map[string]string)
+	s["app"] = objname
+
+	eps := []monitoringv1.PodMetricsEndpoint{}
+	for _, port := range FindServiceMonitorPorts(mg) {
+		eps = append(eps, monitoringv1.PodMetricsEndpoint{
+			TargetPort: &intstr.IntOrString{
+				IntVal: port,
+			},
+			Path:     FindServiceMonitorPath(mg),
+			Scheme:   params.ServiceMonitorScheme,
+			Interval: params.ServiceMonitorInterval,
+		})
+	}
+
+	var obj = monitoringv1.PodMonitor{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodMonitor",
+			APIVersion: "monitoring.coreos.com/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      objname,
+			Namespace: NameSpace,
+			Labels:    l,
+		},
+		Spec: monitoringv1.PodMonitorSpec{
+			JobLabel:            "app",
+			PodMetricsEndpoints: eps,
+			Selector: metav1.LabelSelector{
+				MatchLabels: s,
+			},
+		},
+	}
+
+	if !Dryrun {
+		StorePodMonitor(obj)
+	}
+	if Output {
+		MarshalObject(obj.DeepCopyObject())
+	}
+}
+
+func StorePodMonitor(obj monitoringv1.PodMonitor) {
+	client := k8sclient.GetMonitoringV1Client().PodMonitors(NameSpace)
+	res, _ := client.Get(context.TODO(), obj.Name, metav1.GetOptions{})
+
+	if len(res.ResourceVersion) > 0 {
+		obj.ResourceVersion = res.ResourceVersion
+		_, err := client.Update(context.TODO(), &obj, metav1.UpdateOptions{})
+		if err != nil {
+			log.Error(err)
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Updated PodMonitor: ", obj.Name, " in Namespace: ", NameSpace)
+	} else {
+		_, err := client.Create(context.TODO(), &obj, metav1.CreateOptions{})
+		if err != nil {
+			log.Error(err)
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Created PodMonitor: ", obj.Name, " in Namespace: ", NameSpace)
+	}
+}
+
+func DeletePodMonitor(name string) {
+	client := k8sclient.GetMonitoringV1Client().PodMonitors(NameSpace)
+
+	_, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		fmt.Println("The PodMonitor: ", name, "does not exist in namespace: ", NameSpace, ", skipping...")
+		return
+	}
+
+	err = client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		fmt.Println("Unable to delete PodMonitor: ", name, " in namespace: ", NameSpace)
+		log.Error(err)
+		return
+	}
+	fmt.Println("Deleted PodMonitor: ", name, ", in namespace: ", NameSpace)
+}
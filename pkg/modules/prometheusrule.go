@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	log "k8s.io/klog"
+)
+
+const (
+	availabilityMetricDefault   = "http_server_requests_seconds_count"
+	availabilitySelectorDefault = `status=~"5.."`
+	latencyMetricDefault        = "http_server_requests_seconds"
+)
+
+// Generates a PrometheusRule from the .spec.alerts section of a metaGraf
+// specification. Does nothing if the specification has no alerts.
+func GenPrometheusRule(mg *metagraf.MetaGraf) {
+	objname := Name(mg)
+
+	rules := PrometheusRules(mg)
+	if len(rules) == 0 {
+		log.V(2).Infof("No alerts in specification for: %v", objname)
+		return
+	}
+
+	// Resource labels
+	l := Labels(objname, labelsFromParams(params.Labels))
+	l["app.kubernetes.io/instance"] = objname
+	l["prometheus"] = params.ServiceMonitorOperatorName
+
+	var obj = monitoringv1.PrometheusRule{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PrometheusRule",
+			APIVersion: "monitoring.coreos.com/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      objname,
+			Namespace: NameSpace,
+			Labels:    l,
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{
+				{
+					Name:  objname,
+					Rules: rules,
+				},
+			},
+		},
+	}
+
+	if !Dryrun {
+		StorePrometheusRule(obj)
+	}
+	if Output {
+		MarshalObject(obj.DeepCopyObject())
+	}
+}
+
+// Returns the alerting rules described in a metaGraf specification. Common SLO
+// rules come first, followed by custom rules in the order they are specified.
+func PrometheusRules(mg *metagraf.MetaGraf) []monitoringv1.Rule {
+	var rules []monitoringv1.Rule
+	objname := Name(mg)
+
+	if a := mg.Spec.Alerts.Availability; a != nil {
+		rules = append(rules, availabilityRule(objname, a))
+	}
+	if a := mg.Spec.Alerts.Latency; a != nil {
+		rules = append(rules, latencyRule(objname, a))
+	}
+	for _, a := range mg.Spec.Alerts.Custom {
+		rules = append(rules, customRule(objname, a))
+	}
+	return rules
+}
+
+// Alerts when the ratio of failed requests is larger than the error budget
+// given by the availability objective.
+func availabilityRule(objname string, a *metagraf.AvailabilityAlert) monitoringv1.Rule {
+	metric := valueOrDefault(a.Metric, availabilityMetricDefault)
+	selector := valueOrDefault(a.ErrorSelector, availabilitySelectorDefault)
+	window := valueOrDefault(a.Window, params.PrometheusRuleWindowDefault)
+	budget := strconv.FormatFloat(1-a.Objective/100, 'g', 6, 64)
+
+	expr := fmt.Sprintf(
+		"sum(rate(%v{job=\"%v\",%v}[%v])) / sum(rate(%v{job=\"%v\"}[%v])) > %v",
+		metric, objname, selector, window, metric, objname, window, budget)
+
+	return monitoringv1.Rule{
+		Alert:  "AvailabilitySLO",
+		Expr:   intstr.FromString(expr),
+		For:    valueOrDefault(a.For, params.PrometheusRuleForDefault),
+		Labels: ruleLabels(objname, a.Severity, nil),
+		Annotations: map[string]string{
+			"summary": objname + " availability is below " + strconv.FormatFloat(a.Objective, 'f', -1, 64) + "%",
+		},
+	}
+}
+
+// Alerts when the requested percentile of request durations is above the threshold.
+func latencyRule(objname string, a *metagraf.LatencyAlert) monitoringv1.Rule {
+	metric := valueOrDefault(a.Metric, latencyMetricDefault)
+	window := valueOrDefault(a.Window, params.PrometheusRuleWindowDefault)
+	percentile := strconv.FormatFloat(a.Percentile, 'f', -1, 64)
+	threshold := strconv.FormatFloat(a.Threshold, 'f', -1, 64)
+
+	expr := fmt.Sprintf(
+		"histogram_quantile(%v, sum(rate(%v_bucket{job=\"%v\"}[%v])) by (le)) > %v",
+		percentile, metric, objname, window, threshold)
+
+	return monitoringv1.Rule{
+		Alert:  "LatencySLO",
+		Expr:   intstr.FromString(expr),
+		For:    valueOrDefault(a.For, params.PrometheusRuleForDefault),
+		Labels: ruleLabels(objname, a.Severity, nil),
+		Annotations: map[string]string{
+			"summary": objname + " p" + strconv.FormatFloat(a.Percentile*100, 'f', -1, 64) + " latency is above " + threshold + "s",
+		},
+	}
+}
+
+func customRule(objname string, a metagraf.Alert) monitoringv1.Rule {
+	annotations := make(map[string]string)
+	if len(a.Summary) > 0 {
+		annotations["summary"] = a.Summary
+	}
+	if len(a.Description) > 0 {
+		annotations["description"] = a.Description
+	}
+
+	return monitoringv1.Rule{
+		Alert:       a.Name,
+		Expr:        intstr.FromString(a.Expr),
+		For:         valueOrDefault(a.For, params.PrometheusRuleForDefault),
+		Labels:      ruleLabels(objname, a.Severity, a.Labels),
+		Annotations: annotations,
+	}
+}
+
+func ruleLabels(objname string, severity string, extra map[string]string) map[string]string {
+	l := make(map[string]string)
+	for k, v := range extra {
+		l[k] = v
+	}
+	l["app"] = objname
+	l["severity"] = valueOrDefault(severity, params.PrometheusRuleSeverityDefault)
+	return l
+}
+
+func valueOrDefault(value string, def string) string {
+	if len(value) > 0 {
+		return value
+	}
+	return def
+}
+
+func StorePrometheusRule(obj monitoringv1.PrometheusRule) {
+	client := k8sclient.GetMonitoringV1Client().PrometheusRules(NameSpace)
+	res, _ := client.Get(context.TODO(), obj.Name, metav1.GetOptions{})
+
+	if len(res.ResourceVersion) > 0 {
+		obj.ResourceVersion = res.ResourceVersion
+		_, err := client.Update(context.TODO(), &obj, metav1.UpdateOptions{})
+		if err != nil {
+			log.Error(err)
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Updated PrometheusRule: ", obj.Name, " in Namespace: ", NameSpace)
+	} else {
+		_, err := client.Create(context.TODO(), &obj, metav1.CreateOptions{})
+		if err != nil {
+			log.Error(err)
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Created PrometheusRule: ", obj.Name, " in Namespace: ", NameSpace)
+	}
+}
+
+func DeletePrometheusRule(name string) {
+	client := k8sclient.GetMonitoringV1Client().PrometheusRules(NameSpace)
+
+	_, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		fmt.Println("The PrometheusRule: ", name, "does not exist in namespace: ", NameSpace, ", skipping...")
+		return
+	}
+
+	err = client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		fmt.Println("Unable to delete PrometheusRule: ", name, " in namespace: ", NameSpace)
+		log.Error(err)
+		return
+	}
+	fmt.Println("Deleted PrometheusRule: ", name, ", in namespace: ", NameSpace)
+}
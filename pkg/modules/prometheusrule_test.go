@@ -0,0 +1,72 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+)
+
+func TestPrometheusRules(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "example"
+	mg.Spec.Version = "1.2.3"
+	mg.Spec.Alerts.Availability = &metagraf.AvailabilityAlert{Objective: 99.9}
+	mg.Spec.Alerts.Latency = &metagraf.LatencyAlert{Percentile: 0.99, Threshold: 0.5}
+	mg.Spec.Alerts.Custom = append(mg.Spec.Alerts.Custom, metagraf.Alert{
+		Name:     "QueueBacklog",
+		Expr:     "queue_depth > 100",
+		Severity: "critical",
+	})
+
+	rules := PrometheusRules(&mg)
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %v", len(rules))
+	}
+
+	t.Run("Availability", func(t *testing.T) {
+		expected := `sum(rate(http_server_requests_seconds_count{job="examplev1",status=~"5.."}[5m])) / sum(rate(http_server_requests_seconds_count{job="examplev1"}[5m])) > 0.001`
+		if rules[0].Expr.StrVal != expected {
+			t.Errorf("Expected expression %v, got %v", expected, rules[0].Expr.StrVal)
+		}
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		expected := `histogram_quantile(0.99, sum(rate(http_server_requests_seconds_bucket{job="examplev1"}[5m])) by (le)) > 0.5`
+		if rules[1].Expr.StrVal != expected {
+			t.Errorf("Expected expression %v, got %v", expected, rules[1].Expr.StrVal)
+		}
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		if rules[2].Labels["severity"] != "critical" {
+			t.Errorf("Expected severity critical, got %v", rules[2].Labels["severity"])
+		}
+		if rules[2].Labels["app"] != "examplev1" {
+			t.Errorf("Expected app label examplev1, got %v", rules[2].Labels["app"])
+		}
+	})
+}
+
+func TestFindServiceMonitorPorts(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Annotations = map[string]string{"servicemonitor.monitoring.coreos.com/ports": "http, management"}
+	mg.Spec.Ports = map[string]int32{"http": 8080, "management": 9090, "metrics": 9404, "grpc": 5000}
+
+	ports := FindServiceMonitorPorts(&mg)
+	expected := []int32{8080, 9090, 9404}
+	if len(ports) != len(expected) {
+		t.Fatalf("Expected ports %v, got %v", expected, ports)
+	}
+	for i := range expected {
+		if ports[i] != expected[i] {
+			t.Errorf("Expected ports %v, got %v", expected, ports)
+		}
+	}
+
+	// The port annotation is honored before a port named metrics.
+	mg.Metadata.Annotations = map[string]string{"servicemonitor.monitoring.coreos.com/port": "8778"}
+	ports = FindServiceMonitorPorts(&mg)
+	if len(ports) != 1 || ports[0] != 8778 {
+		t.Errorf("Expected the annotated port 8778, got %v", ports)
+	}
+}
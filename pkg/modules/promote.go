@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/imageinfo"
+	"github.com/laetho/metagraf/pkg/metagraf"
+)
+
+// Returns the conventional reference of the image for mg in location,
+// a registry/namespace, tagged with the version of the component.
+func PromoteRef(mg *metagraf.MetaGraf, location string) (string, error) {
+	version := mg.Spec.Version
+	if len(Version) > 0 {
+		version = Version
+	}
+	if len(version) == 0 {
+		return "", errors.New("metaGraf specification has no version to promote")
+	}
+	return strings.TrimSuffix(location, "/") + "/" + Name(mg) + ":" + version, nil
+}
+
+// Copies the image of mg from one registry/namespace to another.
+// Returns the reference of the promoted image and its digest.
+func Promote(mg *metagraf.MetaGraf, from string, to string) (string, string, error) {
+	src, err := PromoteRef(mg, from)
+	if err != nil {
+		return "", "", err
+	}
+	dst, err := PromoteRef(mg, to)
+	if err != nil {
+		return "", "", err
+	}
+	digest, err := imageinfo.NewRegistryProvider().Copy(src, dst)
+	if err != nil {
+		return "", "", err
+	}
+	return dst, digest, nil
+}
@@ -0,0 +1,72 @@
+package modules
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/laetho/metagraf/pkg/metagraf"
+)
+
+func TestPromote(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.2.3"
+
+	from := u.Host + "/dev"
+	to := u.Host + "/prod/"
+
+	src, err := PromoteRef(&mg, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src != u.Host+"/dev/appv1:1.2.3" {
+		t.Errorf("Unexpected source reference %v", src)
+	}
+
+	// A multi platform image.
+	idx, err := random.Index(64, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = remote.WriteIndex(ref, idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := idx.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst, digest, err := Promote(&mg, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != u.Host+"/prod/appv1:1.2.3" {
+		t.Errorf("Unexpected promoted reference %v", dst)
+	}
+	if digest != want.String() {
+		t.Errorf("Expected digest %v, got %v", want, digest)
+	}
+
+	mg.Spec.Version = "2.0.0"
+	_, _, err = Promote(&mg, from, to)
+	if err == nil {
+		t.Error("Expected promoting a missing image to fail")
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	log "k8s.io/klog"
+)
+
+// Generates Services for the attached resources in spec.resources, so the
+// component can address them by their names through cluster dns.
+// Resources with fixed addresses also get an Endpoints resource.
+func GenResourceServices(mg *metagraf.MetaGraf) {
+	for _, r := range mg.Spec.Resources {
+		svc, ep, ok := genResourceService(mg, r)
+		if !ok {
+			continue
+		}
+
+		if Output && Format == "yaml" {
+			fmt.Println("---")
+		}
+		if !Dryrun {
+			StoreService(svc)
+		}
+		if Output {
+			MarshalObject(svc.DeepCopyObject())
+		}
+		if ep == nil {
+			continue
+		}
+
+		if Output && Format == "yaml" {
+			fmt.Println("---")
+		}
+		if !Dryrun {
+			StoreEndpoints(*ep)
+		}
+		if Output {
+			MarshalObject(ep.DeepCopyObject())
+		}
+	}
+}
+
+// Returns the Service and optional Endpoints for an attached resource.
+// Returns false if the resource needs no Service.
+func genResourceService(mg *metagraf.MetaGraf, r metagraf.Resource) (corev1.Service, *corev1.Endpoints, bool) {
+	st := r.ServiceType()
+	if len(st) == 0 {
+		return corev1.Service{}, nil, false
+	}
+
+	name := strings.ToLower(r.Name)
+	if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+		log.Warningf("Resource %v is not a valid Service name, skipping: %v", r.Name, strings.Join(errs, ", "))
+		return corev1.Service{}, nil, false
+	}
+
+	labels := Labels(Name(mg), labelsFromParams(params.Labels))
+
+	svc := corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: resourceServicePorts(r),
+		},
+	}
+
+	switch st {
+	case metagraf.ExternalName:
+		if len(r.Host) == 0 {
+			log.Warningf("Resource %v has no host for an ExternalName Service, skipping.", r.Name)
+			return corev1.Service{}, nil, false
+		}
+		svc.Spec.Type = corev1.ServiceTypeExternalName
+		svc.Spec.ExternalName = r.Host
+		return svc, nil, true
+	case metagraf.ClusterService:
+		if len(r.Addresses) == 0 || len(svc.Spec.Ports) == 0 {
+			log.Warningf("Resource %v needs addresses and ports for a Service with Endpoints, skipping.", r.Name)
+			return corev1.Service{}, nil, false
+		}
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		svc.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	}
+
+	subset := corev1.EndpointSubset{}
+	for _, a := range r.Addresses {
+		if net.ParseIP(a) == nil {
+			log.Errorf("Resource %v has an invalid ip address: %v", r.Name, a)
+			os.Exit(1)
+		}
+		subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{IP: a})
+	}
+	for _, p := range svc.Spec.Ports {
+		subset.Ports = append(subset.Ports, corev1.EndpointPort{
+			Name:     p.Name,
+			Port:     p.Port,
+			Protocol: p.Protocol,
+		})
+	}
+
+	ep := corev1.Endpoints{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Endpoints",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Subsets: []corev1.EndpointSubset{subset},
+	}
+	return svc, &ep, true
+}
+
+// Service ports of a resource, sorted by name.
+func resourceServicePorts(r metagraf.Resource) []corev1.ServicePort {
+	var names []string
+	for n := range r.Ports {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var ports []corev1.ServicePort
+	for _, n := range names {
+		ports = append(ports, corev1.ServicePort{
+			Name:       strings.ToLower(n),
+			Port:       r.Ports[n],
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(int(r.Ports[n])),
+		})
+	}
+	return ports
+}
+
+func StoreEndpoints(obj corev1.Endpoints) {
+	client := k8sclient.GetCoreClient().Endpoints(NameSpace)
+	ep, _ := client.Get(context.TODO(), obj.Name, metav1.GetOptions{})
+
+	if len(ep.ResourceVersion) > 0 {
+		obj.ResourceVersion = ep.ResourceVersion
+		_, err := client.Update(context.TODO(), &obj, metav1.UpdateOptions{})
+		if err != nil {
+			log.Error(err)
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Updated Endpoints: ", obj.Name, " in Namespace: ", NameSpace)
+	} else {
+		_, err := client.Create(context.TODO(), &obj, metav1.CreateOptions{})
+		if err != nil {
+			log.Error(err)
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Created Endpoints: ", obj.Name, " in Namespace: ", NameSpace)
+	}
+}
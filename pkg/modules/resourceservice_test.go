@@ -0,0 +1,50 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenResourceService(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+
+	_, _, ok := genResourceService(&mg, metagraf.Resource{Name: "other", Type: "service"})
+	if ok {
+		t.Error("Expected no Service for a resource without host or addresses")
+	}
+
+	svc, ep, ok := genResourceService(&mg, metagraf.Resource{
+		Name:     "Payments",
+		External: true,
+		Host:     "payments.example.com",
+		Ports:    map[string]int32{"https": 443},
+	})
+	if !ok || ep != nil {
+		t.Fatalf("Expected an ExternalName Service without Endpoints")
+	}
+	if svc.Name != "payments" || svc.Spec.Type != corev1.ServiceTypeExternalName || svc.Spec.ExternalName != "payments.example.com" {
+		t.Errorf("Unexpected Service %v, type %v, external name %v", svc.Name, svc.Spec.Type, svc.Spec.ExternalName)
+	}
+
+	svc, ep, ok = genResourceService(&mg, metagraf.Resource{
+		Name:      "db",
+		Addresses: []string{"10.0.0.10", "10.0.0.11"},
+		Ports:     map[string]int32{"postgres": 5432, "metrics": 9187},
+	})
+	if !ok || ep == nil {
+		t.Fatalf("Expected a Service with Endpoints")
+	}
+	if svc.Spec.Type != corev1.ServiceTypeClusterIP || len(svc.Spec.Selector) != 0 {
+		t.Errorf("Expected a ClusterIP Service without selector, got %v, %v", svc.Spec.Type, svc.Spec.Selector)
+	}
+	if len(svc.Spec.Ports) != 2 || svc.Spec.Ports[0].Name != "metrics" || svc.Spec.Ports[1].Port != 5432 {
+		t.Errorf("Unexpected ports %v", svc.Spec.Ports)
+	}
+	if ep.Name != "db" || len(ep.Subsets) != 1 || len(ep.Subsets[0].Addresses) != 2 || len(ep.Subsets[0].Ports) != 2 {
+		t.Errorf("Unexpected Endpoints %v", ep)
+	}
+}
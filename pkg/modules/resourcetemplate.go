@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	log "k8s.io/klog"
+)
+
+// Data available to the template of an attached resource. The fields of
+// the resource are available directly, example {{ .Host }} or {{ .User }}.
+type ResourceTemplateData struct {
+	metagraf.Resource
+	// Name of the component, as used for its Kubernetes resources.
+	Component string
+	// Resolved configuration values keyed by property key.
+	Properties map[string]string
+	// Name of the Secret holding credentials for the resource and
+	// the directory it is mounted in.
+	SecretName string
+	SecretPath string
+}
+
+// Key of the rendered template in the ConfigMap of a resource, and the
+// name of the file it is mounted as in /mg/resource.
+func resourceConfigKey(r *metagraf.Resource) string {
+	return strings.ToLower(strings.Replace(r.Name, "_", "-", -1))
+}
+
+func resourceConfigMapName(mg *metagraf.MetaGraf, r *metagraf.Resource) string {
+	return Name(mg) + "-" + strings.Replace(resourceConfigKey(r), ".", "-", -1)
+}
+
+// Executes the template of a resource with the resource fields, resolved
+// properties and secret references as data.
+func renderResourceTemplate(mg *metagraf.MetaGraf, r *metagraf.Resource, mgp metagraf.MGProperties) (string, error) {
+	tmpl, err := template.New(r.Name).Option("missingkey=error").Parse(r.Template)
+	if err != nil {
+		return "", err
+	}
+
+	props := make(map[string]string)
+	for _, p := range mgp {
+		if len(p.Value) > 0 {
+			props[p.Key] = p.Value
+		} else {
+			props[p.Key] = p.Default
+		}
+	}
+
+	data := ResourceTemplateData{
+		Resource:   *r,
+		Component:  Name(mg),
+		Properties: props,
+	}
+	if len(r.User) > 0 || len(r.Secret) > 0 {
+		data.SecretName = ResourceSecretName(r)
+		data.SecretPath = "/mg/secret/" + data.SecretName
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Generates a ConfigMap with the rendered template of a resource.
+func genResourceConfigMap(mg *metagraf.MetaGraf, r *metagraf.Resource, mgp metagraf.MGProperties) (corev1.ConfigMap, error) {
+	config, err := renderResourceTemplate(mg, r, mgp)
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+
+	return corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   resourceConfigMapName(mg, r),
+			Labels: Labels(Name(mg), labelsFromParams(params.Labels)),
+		},
+		Data: map[string]string{
+			resourceConfigKey(r): config,
+		},
+	}, nil
+}
+
+// Environment variables exporting rendered resource templates for
+// resources with an EnvRef.
+func resourceTemplateEnvVars(mg *metagraf.MetaGraf) []corev1.EnvVar {
+	var envs []corev1.EnvVar
+	for i := range mg.Spec.Resources {
+		r := &mg.Spec.Resources[i]
+		if len(r.Template) == 0 || len(r.EnvRef) == 0 {
+			continue
+		}
+		envs = append(envs, corev1.EnvVar{
+			Name: r.EnvRef,
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: resourceConfigMapName(mg, r),
+					},
+					Key: resourceConfigKey(r),
+				},
+			},
+		})
+	}
+	return envs
+}
+
+// Renders the templates of attached resources into ConfigMaps.
+func genConfigMapsFromResources(mg *metagraf.MetaGraf) {
+	for i := range mg.Spec.Resources {
+		r := &mg.Spec.Resources[i]
+		if len(r.Template) == 0 {
+			continue
+		}
+
+		cm, err := genResourceConfigMap(mg, r, Variables)
+		if err != nil {
+			log.Errorf("Unable to render template for resource %v: %v", r.Name, err)
+			os.Exit(1)
+		}
+		if !Dryrun {
+			StoreConfigMap(cm)
+		}
+		if Output {
+			MarshalObject(cm.DeepCopyObject())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+)
+
+func TestGenResourceConfigMap(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Resources = []metagraf.Resource{
+		{
+			Name:     "Orders_DB",
+			User:     "orders",
+			Host:     "db.example.com",
+			Template: "url=jdbc:postgresql://{{ .Host }}/{{ .Properties.dbname }}\nuser={{ .User }}\npasswordfile={{ .SecretPath }}/password\n",
+		},
+		{
+			Name:     "cache",
+			EnvRef:   "CACHE_URL",
+			Template: "redis://{{ .Name }}:6379",
+		},
+	}
+
+	mgp := metagraf.MGProperties{}
+	mgp["local|dbname"] = metagraf.MGProperty{Source: "local", Key: "dbname", Default: "orders"}
+
+	cm, err := genResourceConfigMap(&mg, &mg.Spec.Resources[0], mgp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Name != "appv1-orders-db" {
+		t.Errorf("Expected ConfigMap appv1-orders-db, got %v", cm.Name)
+	}
+	expected := "url=jdbc:postgresql://db.example.com/orders\nuser=orders\npasswordfile=/mg/secret/orders-db-orders/password\n"
+	if cm.Data["orders-db"] != expected {
+		t.Errorf("Expected %q, got %q", expected, cm.Data["orders-db"])
+	}
+
+	_, err = renderResourceTemplate(&mg, &metagraf.Resource{Name: "x", Template: "{{ .Properties.missing }}"}, mgp)
+	if err == nil {
+		t.Error("Expected an error for a missing property")
+	}
+
+	mounts := FindMetagrafConfigMaps(&mg)
+	if len(mounts) != 1 || mounts[0].Name != "appv1-orders-db" || mounts[0].MountPath != "/mg/resource/orders-db" || mounts[0].SubPath != "orders-db" {
+		t.Errorf("Expected only orders-db to be mounted, got %v", mounts)
+	}
+
+	envs := resourceTemplateEnvVars(&mg)
+	if len(envs) != 1 || envs[0].Name != "CACHE_URL" || envs[0].ValueFrom.ConfigMapKeyRef.Name != "appv1-cache" {
+		t.Errorf("Unexpected env vars %v", envs)
+	}
+}
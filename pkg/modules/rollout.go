@@ -0,0 +1,399 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	log "k8s.io/klog"
+)
+
+// Rollout strategies of spec.rollout.
+const (
+	RolloutRollingUpdate = "RollingUpdate"
+	RolloutRecreate      = "Recreate"
+	RolloutCanary        = "Canary"
+	RolloutBlueGreen     = "BlueGreen"
+)
+
+var (
+	rolloutResource          = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	analysisTemplateResource = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "analysistemplates"}
+)
+
+// Argo Rollouts Rollout, a Deployment with canary or blue-green strategies.
+type Rollout struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              RolloutSpec `json:"spec"`
+}
+
+type RolloutSpec struct {
+	Replicas             *int32                 `json:"replicas,omitempty"`
+	RevisionHistoryLimit *int32                 `json:"revisionHistoryLimit,omitempty"`
+	Selector             *metav1.LabelSelector  `json:"selector"`
+	Template             corev1.PodTemplateSpec `json:"template"`
+	Strategy             RolloutStrategy        `json:"strategy"`
+}
+
+type RolloutStrategy struct {
+	Canary    *CanaryStrategy    `json:"canary,omitempty"`
+	BlueGreen *BlueGreenStrategy `json:"blueGreen,omitempty"`
+}
+
+type CanaryStrategy struct {
+	MaxSurge       *intstr.IntOrString `json:"maxSurge,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	Steps          []CanaryStep        `json:"steps,omitempty"`
+	// Analysis running in the background during the canary.
+	Analysis *RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+type CanaryStep struct {
+	SetWeight *int32           `json:"setWeight,omitempty"`
+	Pause     *RolloutPause    `json:"pause,omitempty"`
+	Analysis  *RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+type RolloutPause struct {
+	Duration *intstr.IntOrString `json:"duration,omitempty"`
+}
+
+type BlueGreenStrategy struct {
+	ActiveService         string           `json:"activeService"`
+	PreviewService        string           `json:"previewService,omitempty"`
+	AutoPromotionEnabled  *bool            `json:"autoPromotionEnabled,omitempty"`
+	AutoPromotionSeconds  int32            `json:"autoPromotionSeconds,omitempty"`
+	ScaleDownDelaySeconds *int32           `json:"scaleDownDelaySeconds,omitempty"`
+	PrePromotionAnalysis  *RolloutAnalysis `json:"prePromotionAnalysis,omitempty"`
+}
+
+// Reference from a Rollout to the AnalysisTemplates to run.
+type RolloutAnalysis struct {
+	Templates []AnalysisTemplateRef `json:"templates"`
+	Args      []AnalysisRunArgument `json:"args,omitempty"`
+}
+
+type AnalysisTemplateRef struct {
+	TemplateName string `json:"templateName"`
+}
+
+type AnalysisRunArgument struct {
+	Name      string             `json:"name"`
+	Value     string             `json:"value,omitempty"`
+	ValueFrom *ArgumentValueFrom `json:"valueFrom,omitempty"`
+}
+
+type ArgumentValueFrom struct {
+	FieldRef *corev1.ObjectFieldSelector `json:"fieldRef,omitempty"`
+}
+
+// Argo Rollouts AnalysisTemplate, metrics deciding whether a new version
+// is promoted or aborted.
+type AnalysisTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              AnalysisTemplateSpec `json:"spec"`
+}
+
+type AnalysisTemplateSpec struct {
+	Args    []AnalysisArgument `json:"args,omitempty"`
+	Metrics []AnalysisMetric   `json:"metrics"`
+}
+
+type AnalysisArgument struct {
+	Name string `json:"name"`
+}
+
+type AnalysisMetric struct {
+	Name             string                 `json:"name"`
+	Interval         string                 `json:"interval,omitempty"`
+	Count            *int32                 `json:"count,omitempty"`
+	FailureLimit     *int32                 `json:"failureLimit,omitempty"`
+	SuccessCondition string                 `json:"successCondition"`
+	Provider         AnalysisMetricProvider `json:"provider"`
+}
+
+type AnalysisMetricProvider struct {
+	Prometheus *PrometheusMetric `json:"prometheus,omitempty"`
+}
+
+type PrometheusMetric struct {
+	Address string `json:"address"`
+	Query   string `json:"query"`
+}
+
+// Returns the rollout strategy of spec.rollout, RollingUpdate if not set.
+// Exits on unknown strategies.
+func rolloutStrategy(mg *metagraf.MetaGraf) string {
+	if mg.Spec.Rollout == nil || len(mg.Spec.Rollout.Strategy) == 0 {
+		return RolloutRollingUpdate
+	}
+	for _, s := range []string{RolloutRollingUpdate, RolloutRecreate, RolloutCanary, RolloutBlueGreen} {
+		if strings.EqualFold(mg.Spec.Rollout.Strategy, s) {
+			return s
+		}
+	}
+	log.Errorf("Unsupported rollout strategy %v, use RollingUpdate, Recreate, Canary or BlueGreen.", mg.Spec.Rollout.Strategy)
+	os.Exit(1)
+	return ""
+}
+
+// Reports whether the component is rolled out with Argo Rollouts.
+func IsProgressiveRollout(mg *metagraf.MetaGraf) bool {
+	s := rolloutStrategy(mg)
+	return s == RolloutCanary || s == RolloutBlueGreen
+}
+
+// Max surge and max unavailable of spec.rollout, 25% if not set.
+func rolloutRollingParams(mg *metagraf.MetaGraf) (*intstr.IntOrString, *intstr.IntOrString) {
+	surge := intstr.FromString("25%")
+	unavailable := intstr.FromString("25%")
+	if mg.Spec.Rollout != nil && mg.Spec.Rollout.MaxSurge != nil {
+		surge = *mg.Spec.Rollout.MaxSurge
+	}
+	if mg.Spec.Rollout != nil && mg.Spec.Rollout.MaxUnavailable != nil {
+		unavailable = *mg.Spec.Rollout.MaxUnavailable
+	}
+	return &surge, &unavailable
+}
+
+func previewServiceName(mg *metagraf.MetaGraf) string {
+	return Name(mg) + "-preview"
+}
+
+// Generates a Rollout running template, with the preview Service and
+// AnalysisTemplate the strategy needs.
+func GenRollout(mg *metagraf.MetaGraf, meta metav1.ObjectMeta, replicas int32, selector map[string]string, template corev1.PodTemplateSpec) {
+	if at, ok := genAnalysisTemplate(mg); ok {
+		storeOrOutput(at, at.Name, analysisTemplateResource)
+		if Output && Format == "yaml" {
+			fmt.Println("---")
+		}
+	}
+
+	if rolloutStrategy(mg) == RolloutBlueGreen {
+		svc := genPreviewService(mg)
+		if !Dryrun {
+			StoreService(svc)
+		}
+		if Output {
+			MarshalObject(svc.DeepCopyObject())
+			if Format == "yaml" {
+				fmt.Println("---")
+			}
+		}
+	}
+
+	obj := genRollout(mg, meta, replicas, selector, template)
+	storeOrOutput(obj, obj.Name, rolloutResource)
+}
+
+// Deletes the Deployment a Rollout replaces, so the pods of both do not
+// run behind the same Service. Called after the Rollout is stored.
+func deleteReplacedDeployment(name string) {
+	client := k8sclient.GetKubernetesClient().AppsV1().Deployments(NameSpace)
+	err := client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	fmt.Println("Deleted Deployment: ", name, " replaced by Rollout in Namespace: ", NameSpace)
+}
+
+// Deletes the DeploymentConfig a Rollout replaces.
+func deleteReplacedDeploymentConfig(name string) {
+	client := k8sclient.GetAppsClient().DeploymentConfigs(NameSpace)
+	err := client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	fmt.Println("Deleted DeploymentConfig: ", name, " replaced by Rollout in Namespace: ", NameSpace)
+}
+
+func genRollout(mg *metagraf.MetaGraf, meta metav1.ObjectMeta, replicas int32, selector map[string]string, template corev1.PodTemplateSpec) Rollout {
+	var RevisionHistoryLimit int32 = 5
+
+	obj := Rollout{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Rollout",
+			APIVersion: "argoproj.io/v1alpha1",
+		},
+		ObjectMeta: meta,
+		Spec: RolloutSpec{
+			Replicas:             &replicas,
+			RevisionHistoryLimit: &RevisionHistoryLimit,
+			Selector:             &metav1.LabelSelector{MatchLabels: selector},
+			Template:             template,
+		},
+	}
+
+	var analysis *RolloutAnalysis
+	if mg.Spec.Rollout.Analysis != nil {
+		analysis = rolloutAnalysis(mg)
+	}
+
+	switch rolloutStrategy(mg) {
+	case RolloutCanary:
+		surge, unavailable := rolloutRollingParams(mg)
+		canary := CanaryStrategy{
+			MaxSurge:       surge,
+			MaxUnavailable: unavailable,
+		}
+		analysisStep := false
+		if mg.Spec.Rollout.Canary != nil {
+			for _, s := range mg.Spec.Rollout.Canary.Steps {
+				canary.Steps = append(canary.Steps, canaryStep(s, analysis)...)
+				analysisStep = analysisStep || (s.Analysis && analysis != nil)
+			}
+		}
+		if !analysisStep {
+			canary.Analysis = analysis
+		}
+		obj.Spec.Strategy.Canary = &canary
+	case RolloutBlueGreen:
+		bluegreen := BlueGreenStrategy{
+			ActiveService:        Name(mg),
+			PreviewService:       previewServiceName(mg),
+			PrePromotionAnalysis: analysis,
+		}
+		if bg := mg.Spec.Rollout.BlueGreen; bg != nil {
+			bluegreen.AutoPromotionEnabled = bg.AutoPromotionEnabled
+			bluegreen.AutoPromotionSeconds = bg.AutoPromotionSeconds
+			bluegreen.ScaleDownDelaySeconds = bg.ScaleDownDelaySeconds
+		}
+		obj.Spec.Strategy.BlueGreen = &bluegreen
+	}
+	return obj
+}
+
+// Converts a canary step of the specification into Rollout steps. Argo
+// Rollouts steps hold a single action each.
+func canaryStep(s metagraf.CanaryStep, analysis *RolloutAnalysis) []CanaryStep {
+	var steps []CanaryStep
+	if s.SetWeight != nil {
+		steps = append(steps, CanaryStep{SetWeight: s.SetWeight})
+	}
+	if s.Pause != nil {
+		pause := RolloutPause{}
+		if len(*s.Pause) > 0 {
+			d := intstr.Parse(*s.Pause)
+			pause.Duration = &d
+		}
+		steps = append(steps, CanaryStep{Pause: &pause})
+	}
+	if s.Analysis && analysis != nil {
+		steps = append(steps, CanaryStep{Analysis: analysis})
+	}
+	return steps
+}
+
+func rolloutAnalysis(mg *metagraf.MetaGraf) *RolloutAnalysis {
+	return &RolloutAnalysis{
+		Templates: []AnalysisTemplateRef{{TemplateName: Name(mg)}},
+		Args: []AnalysisRunArgument{
+			{Name: "service-name", Value: Name(mg)},
+			{Name: "namespace", ValueFrom: &ArgumentValueFrom{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+			}},
+		},
+	}
+}
+
+// Generates the AnalysisTemplate of spec.rollout.analysis. Returns false
+// if the specification has no analysis.
+func genAnalysisTemplate(mg *metagraf.MetaGraf) (AnalysisTemplate, bool) {
+	if mg.Spec.Rollout == nil || mg.Spec.Rollout.Analysis == nil {
+		return AnalysisTemplate{}, false
+	}
+	a := mg.Spec.Rollout.Analysis
+	if len(a.PrometheusAddress) == 0 {
+		log.Errorf("%v: spec.rollout.analysis requires a prometheusAddress", Name(mg))
+		os.Exit(1)
+	}
+
+	metrics := a.Metrics
+	if len(metrics) == 0 {
+		// Scrape targets are labelled with the app label of the Service
+		// as job by the generated ServiceMonitor.
+		metrics = []metagraf.AnalysisMetric{{
+			Name:             "up",
+			Query:            `min(up{job="{{args.service-name}}",namespace="{{args.namespace}}"})`,
+			SuccessCondition: "result[0] == 1",
+			Interval:         "1m",
+		}}
+	}
+
+	obj := AnalysisTemplate{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AnalysisTemplate",
+			APIVersion: "argoproj.io/v1alpha1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   Name(mg),
+			Labels: Labels(Name(mg), labelsFromParams(params.Labels)),
+		},
+		Spec: AnalysisTemplateSpec{
+			Args: []AnalysisArgument{{Name: "service-name"}, {Name: "namespace"}},
+		},
+	}
+	for _, m := range metrics {
+		obj.Spec.Metrics = append(obj.Spec.Metrics, AnalysisMetric{
+			Name:             m.Name,
+			Interval:         m.Interval,
+			Count:            m.Count,
+			FailureLimit:     m.FailureLimit,
+			SuccessCondition: m.SuccessCondition,
+			Provider: AnalysisMetricProvider{
+				Prometheus: &PrometheusMetric{Address: a.PrometheusAddress, Query: m.Query},
+			},
+		})
+	}
+	return obj, true
+}
+
+// The Service of the component, selecting the new version during a
+// blue-green rollout. Always of type ClusterIP.
+func genPreviewService(mg *metagraf.MetaGraf) corev1.Service {
+	svc := genService(mg)
+	svc.Name = previewServiceName(mg)
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	svc.Spec.ExternalTrafficPolicy = ""
+	svc.Spec.LoadBalancerSourceRanges = nil
+	for i := range svc.Spec.Ports {
+		svc.Spec.Ports[i].NodePort = 0
+	}
+	return svc
+}
@@ -0,0 +1,91 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestRolloutStrategy(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	if rolloutStrategy(&mg) != RolloutRollingUpdate || IsProgressiveRollout(&mg) {
+		t.Errorf("Expected RollingUpdate by default, got %v", rolloutStrategy(&mg))
+	}
+	surge, unavailable := rolloutRollingParams(&mg)
+	if surge.StrVal != "25%" || unavailable.StrVal != "25%" {
+		t.Errorf("Expected 25%% surge and unavailable by default, got %v, %v", surge, unavailable)
+	}
+
+	one := intstr.FromInt(1)
+	mg.Spec.Rollout = &metagraf.Rollout{Strategy: "canary", MaxUnavailable: &one}
+	if rolloutStrategy(&mg) != RolloutCanary || !IsProgressiveRollout(&mg) {
+		t.Errorf("Expected Canary, got %v", rolloutStrategy(&mg))
+	}
+	if _, unavailable := rolloutRollingParams(&mg); unavailable.IntVal != 1 {
+		t.Errorf("Expected max unavailable 1, got %v", unavailable)
+	}
+}
+
+func TestGenRollout(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+
+	weight := int32(20)
+	pause := "5m"
+	indefinite := ""
+	mg.Spec.Rollout = &metagraf.Rollout{
+		Strategy: RolloutCanary,
+		Canary: &metagraf.CanaryRollout{Steps: []metagraf.CanaryStep{
+			{SetWeight: &weight, Pause: &pause},
+			{Analysis: true},
+			{Pause: &indefinite},
+		}},
+		Analysis: &metagraf.RolloutAnalysis{PrometheusAddress: "http://prometheus:9090"},
+	}
+
+	meta := metav1.ObjectMeta{Name: "appv1"}
+	selector := map[string]string{"app": "appv1"}
+	template := corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: selector}}
+
+	obj := genRollout(&mg, meta, 2, selector, template)
+	canary := obj.Spec.Strategy.Canary
+	if canary == nil || obj.Spec.Strategy.BlueGreen != nil {
+		t.Fatalf("Expected a canary strategy, got %v", obj.Spec.Strategy)
+	}
+	if len(canary.Steps) != 4 || *canary.Steps[0].SetWeight != 20 || canary.Steps[1].Pause.Duration.StrVal != "5m" {
+		t.Errorf("Unexpected canary steps %v", canary.Steps)
+	}
+	if canary.Steps[2].Analysis == nil || canary.Analysis != nil {
+		t.Errorf("Expected the analysis in a step only, got %v", canary)
+	}
+	if canary.Steps[3].Pause == nil || canary.Steps[3].Pause.Duration != nil {
+		t.Errorf("Expected an indefinite pause, got %v", canary.Steps[3])
+	}
+	if *obj.Spec.Replicas != 2 || obj.Spec.Selector.MatchLabels["app"] != "appv1" {
+		t.Errorf("Unexpected replicas or selector %v", obj.Spec)
+	}
+
+	at, ok := genAnalysisTemplate(&mg)
+	if !ok || at.Name != "appv1" || len(at.Spec.Metrics) != 1 || at.Spec.Metrics[0].Provider.Prometheus.Address != "http://prometheus:9090" {
+		t.Errorf("Unexpected default AnalysisTemplate %v", at)
+	}
+
+	mg.Spec.Rollout.Strategy = RolloutBlueGreen
+	promote := false
+	mg.Spec.Rollout.BlueGreen = &metagraf.BlueGreenRollout{AutoPromotionEnabled: &promote}
+	obj = genRollout(&mg, meta, 2, selector, template)
+	bg := obj.Spec.Strategy.BlueGreen
+	if bg == nil || bg.ActiveService != "appv1" || bg.PreviewService != "appv1-preview" || *bg.AutoPromotionEnabled || bg.PrePromotionAnalysis == nil {
+		t.Errorf("Unexpected blue-green strategy %v", bg)
+	}
+
+	mg.Spec.Service = &metagraf.Service{Type: corev1.ServiceTypeNodePort}
+	svc := genPreviewService(&mg)
+	if svc.Name != "appv1-preview" || svc.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("Unexpected preview Service %v", svc)
+	}
+}
@@ -23,8 +23,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/laetho/metagraf/internal/pkg/helpers"
-	"github.com/laetho/metagraf/internal/pkg/imageurl"
 	"github.com/laetho/metagraf/internal/pkg/k8sclient"
 	"github.com/laetho/metagraf/internal/pkg/params"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -33,62 +31,44 @@ import (
 	"github.com/laetho/metagraf/pkg/metagraf"
 
 	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func GenRoute(mg *metagraf.MetaGraf) {
-	var weight int32 = 100
+	for _, obj := range genRoutes(mg) {
+		if !Dryrun {
+			StoreRoute(obj)
+		}
+		if Output {
+			MarshalObject(obj.DeepCopyObject())
+		}
+	}
+}
 
+// Generates the Route for the component and one Route for each entry
+// in spec.route.paths.
+func genRoutes(mg *metagraf.MetaGraf) []routev1.Route {
 	objname := Name(mg)
 
-	var DockerImage string
-	if len(mg.Spec.BaseRunImage) > 0 {
-		DockerImage = mg.Spec.BaseRunImage
-	} else if len(mg.Spec.BuildImage) > 0 {
-		DockerImage = mg.Spec.BuildImage
-	} else if len(mg.Spec.Image) > 0 {
-		DockerImage = mg.Spec.Image
-	} else {
-		DockerImage = ""
+	spec := metagraf.Route{}
+	if mg.Spec.Route != nil {
+		spec = *mg.Spec.Route
 	}
-
-	client := k8sclient.GetImageClient()
-	var imgurl imageurl.ImageURL
-	err := imgurl.Parse(DockerImage)
-	ist := helpers.GetImageStreamTags(
-		client,
-		imgurl.Namespace,
-		imgurl.Image+":"+imgurl.Tag)
-	if err != nil {
-		log.Errorf("%v", err)
+	if len(params.RouteHost) > 0 {
+		spec.Host = params.RouteHost
 	}
-
-	ImageInfo := helpers.GetDockerImageFromIST(ist)
-	log.V(2).Infof("Docker image ports: %v", ImageInfo.Config.ExposedPorts)
-
-	serviceports := GetServicePorts(mg, helpers.ImageExposedPortsToServicePorts(ImageInfo.Config))
-	// Find http port
-	// todo: This needs to be more solid
-	var ports []string
-	var http bool = false
-	for _, port := range serviceports {
-		if port.Name == "http" {
-			ports = append(ports, "http")
-			http = true
-		}
+	if len(spec.Port) == 0 {
+		ImageInfo, _ := mgImageInfo(mg)
+		log.V(2).Infof("Docker image ports: %v", ImageInfo.ExposedPorts)
+		spec.Port = defaultRoutePort(GetServicePorts(mg, ImageInfo.ServicePorts()))
 	}
 
-	if !http {
-		for _, port := range serviceports {
-			ports = append(ports, port.Name)
-		}
-	}
-
-	sort.Strings(ports)
-
 	// Resource labels
 	l := Labels(objname, labelsFromParams(params.Labels))
 
+	to, alternates := routeBackends(mg, spec)
+
 	obj := routev1.Route{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Route",
@@ -99,27 +79,134 @@ func GenRoute(mg *metagraf.MetaGraf) {
 			Labels: l,
 		},
 		Spec: routev1.RouteSpec{
-			To: routev1.RouteTargetReference{
-				Kind:   "Service",
-				Name:   objname,
-				Weight: &weight,
-			},
-			Path: Context,
-			Port: &routev1.RoutePort{
-				TargetPort: intstr.IntOrString{
-					Type:   1,
-					StrVal: strings.Replace(ports[0], "/", "-", -1),
-				},
-			},
+			Host:              spec.Host,
+			To:                to,
+			AlternateBackends: alternates,
+			Path:              Context,
+			Port:              routePort(spec.Port),
+			TLS:               routeTLS(spec.TLS),
 		},
 	}
 
-	if !Dryrun {
-		StoreRoute(obj)
+	routes := []routev1.Route{obj}
+	for _, p := range spec.Paths {
+		pr := *obj.DeepCopy()
+		pr.Name = objname + "-" + strings.ToLower(p.Name)
+		pr.Spec.Path = p.Path
+		if len(p.Host) > 0 {
+			pr.Spec.Host = p.Host
+		}
+		if len(p.Port) > 0 {
+			pr.Spec.Port = routePort(p.Port)
+		}
+		routes = append(routes, pr)
+	}
+	return routes
+}
+
+// Returns the http service port, or the first service port by name.
+// Returns an empty string if there are no service ports.
+func defaultRoutePort(serviceports []corev1.ServicePort) string {
+	var ports []string
+	for _, port := range serviceports {
+		if port.Name == "http" {
+			return port.Name
+		}
+		ports = append(ports, port.Name)
+	}
+	if len(ports) == 0 {
+		log.Warning("No service ports found, the Route will target all ports of the Service.")
+		return ""
+	}
+	sort.Strings(ports)
+	return ports[0]
+}
+
+func routePort(name string) *routev1.RoutePort {
+	if len(name) == 0 {
+		return nil
+	}
+	return &routev1.RoutePort{
+		TargetPort: intstr.FromString(strings.Replace(name, "/", "-", -1)),
+	}
+}
+
+// Returns the Service of the current version and the Services of the
+// alternate versions of the component, with their weights.
+func routeBackends(mg *metagraf.MetaGraf, spec metagraf.Route) (routev1.RouteTargetReference, []routev1.RouteTargetReference) {
+	var weight int32 = 100
+	if spec.Weight != nil {
+		weight = *spec.Weight
 	}
-	if Output {
-		MarshalObject(obj.DeepCopyObject())
+	to := routev1.RouteTargetReference{
+		Kind:   "Service",
+		Name:   Name(mg),
+		Weight: &weight,
 	}
+
+	var alternates []routev1.RouteTargetReference
+	for _, b := range spec.AlternateBackends {
+		w := b.Weight
+		alternates = append(alternates, routev1.RouteTargetReference{
+			Kind:   "Service",
+			Name:   mg.Name(OName, b.Version),
+			Weight: &w,
+		})
+	}
+	return to, alternates
+}
+
+func routeTLS(tls *metagraf.RouteTLS) *routev1.TLSConfig {
+	if tls == nil {
+		return nil
+	}
+
+	obj := routev1.TLSConfig{
+		Termination:                   routev1.TLSTerminationType(strings.ToLower(tls.Termination)),
+		InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyType(tls.InsecureEdgeTerminationPolicy),
+	}
+	switch obj.Termination {
+	case routev1.TLSTerminationEdge, routev1.TLSTerminationReencrypt:
+	case routev1.TLSTerminationPassthrough:
+		if len(tls.SecretRef) > 0 || len(tls.DestinationCASecretRef) > 0 {
+			log.Warning("Certificates are not used with passthrough termination, ignoring secret references.")
+		}
+		return &obj
+	default:
+		log.Errorf("Unknown route termination %v, use edge, reencrypt or passthrough.", tls.Termination)
+		os.Exit(1)
+	}
+
+	if len(tls.SecretRef) > 0 {
+		data := routeSecretData(tls.SecretRef)
+		obj.Certificate = string(data[corev1.TLSCertKey])
+		obj.Key = string(data[corev1.TLSPrivateKeyKey])
+		obj.CACertificate = string(data["ca.crt"])
+	}
+	if len(tls.DestinationCASecretRef) > 0 {
+		if obj.Termination != routev1.TLSTerminationReencrypt {
+			log.Warning("Destination CA certificate is only used with reencrypt termination.")
+		} else {
+			data := routeSecretData(tls.DestinationCASecretRef)
+			obj.DestinationCACertificate = string(data["ca.crt"])
+		}
+	}
+	return &obj
+}
+
+// Reads certificate material from the named Secret. Certificates are not
+// read from the cluster in a dry run, the Route is generated without them.
+func routeSecretData(name string) map[string][]byte {
+	if Dryrun {
+		log.Warningf("Not reading certificates from Secret %v in a dry run", name)
+		return nil
+	}
+	sec, err := GetSecret(name)
+	if err != nil {
+		log.Errorf("Unable to read certificates from Secret %v: %v", name, err)
+		os.Exit(1)
+	}
+	return sec.Data
 }
 
 func StoreRoute(obj routev1.Route) {
@@ -161,3 +248,14 @@ func DeleteRoute(name string) {
 	}
 	fmt.Println("Deleted Route: ", name, ", in namespace: ", NameSpace)
 }
+
+// Deletes the Route of the component and the Routes of spec.route.paths.
+func DeleteRoutes(mg *metagraf.MetaGraf) {
+	DeleteRoute(Name(mg))
+	if mg.Spec.Route == nil {
+		return
+	}
+	for _, p := range mg.Spec.Route.Paths {
+		DeleteRoute(Name(mg) + "-" + strings.ToLower(p.Name))
+	}
+}
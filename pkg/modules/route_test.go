@@ -0,0 +1,88 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDefaultRoutePort(t *testing.T) {
+	if port := defaultRoutePort(nil); port != "" {
+		t.Errorf("Expected no port without service ports, got %v", port)
+	}
+	ports := []corev1.ServicePort{{Name: "metrics"}, {Name: "https"}}
+	if port := defaultRoutePort(ports); port != "https" {
+		t.Errorf("Expected https, got %v", port)
+	}
+	ports = append(ports, corev1.ServicePort{Name: "http"})
+	if port := defaultRoutePort(ports); port != "http" {
+		t.Errorf("Expected http, got %v", port)
+	}
+}
+
+func TestGenRoutes(t *testing.T) {
+	var weight int32 = 90
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "2.1.0"
+	mg.Spec.Route = &metagraf.Route{
+		Host:   "app.example.com",
+		Port:   "http",
+		Weight: &weight,
+		TLS: &metagraf.RouteTLS{
+			Termination:                   "Edge",
+			InsecureEdgeTerminationPolicy: "Redirect",
+		},
+		Paths: []metagraf.RoutePath{
+			{Name: "API", Path: "/api", Port: "grpc"},
+		},
+		AlternateBackends: []metagraf.RouteBackend{
+			{Version: "1.4.0", Weight: 10},
+		},
+	}
+
+	routes := genRoutes(&mg)
+	if len(routes) != 2 {
+		t.Fatalf("Expected 2 routes, got %v", len(routes))
+	}
+
+	r := routes[0]
+	if r.Name != "appv2" || r.Spec.Host != "app.example.com" || r.Spec.Port.TargetPort.StrVal != "http" {
+		t.Errorf("Unexpected route %v, host %v, port %v", r.Name, r.Spec.Host, r.Spec.Port)
+	}
+	if r.Spec.TLS == nil || r.Spec.TLS.Termination != routev1.TLSTerminationEdge || r.Spec.TLS.InsecureEdgeTerminationPolicy != routev1.InsecureEdgeTerminationPolicyRedirect {
+		t.Errorf("Unexpected TLS config %v", r.Spec.TLS)
+	}
+	if r.Spec.To.Name != "appv2" || *r.Spec.To.Weight != 90 {
+		t.Errorf("Unexpected backend %v", r.Spec.To)
+	}
+	if len(r.Spec.AlternateBackends) != 1 || r.Spec.AlternateBackends[0].Name != "appv1" || *r.Spec.AlternateBackends[0].Weight != 10 {
+		t.Errorf("Unexpected alternate backends %v", r.Spec.AlternateBackends)
+	}
+
+	p := routes[1]
+	if p.Name != "appv2-api" || p.Spec.Path != "/api" || p.Spec.Host != "app.example.com" || p.Spec.Port.TargetPort.StrVal != "grpc" {
+		t.Errorf("Unexpected path route %v, path %v, host %v, port %v", p.Name, p.Spec.Path, p.Spec.Host, p.Spec.Port)
+	}
+}
+
+func TestRouteTLSDryrun(t *testing.T) {
+	Dryrun = true
+	defer func() { Dryrun = false }()
+
+	// Without a cluster the certificates are skipped in a dry run
+	// instead of building a client.
+	tls := routeTLS(&metagraf.RouteTLS{
+		Termination:            "reencrypt",
+		SecretRef:              "app-tls",
+		DestinationCASecretRef: "app-ca",
+	})
+	if tls == nil || tls.Termination != routev1.TLSTerminationReencrypt {
+		t.Fatalf("Expected reencrypt TLS config, got %v", tls)
+	}
+	if len(tls.Certificate) > 0 || len(tls.Key) > 0 || len(tls.DestinationCACertificate) > 0 {
+		t.Errorf("Expected no certificates in a dry run, got %v", tls)
+	}
+}
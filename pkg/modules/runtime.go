@@ -0,0 +1,384 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	log "k8s.io/klog"
+)
+
+// Annotation selecting the runtime profile of a component. Takes
+// precedence over spec.type.
+const RuntimeAnnotation = "metagraf.io/runtime"
+
+// Config type for options handed to the runtime of a component.
+const RuntimeConfigType = "RUNTIME"
+
+// A runtime option from a config section with its input value.
+type RuntimeOption struct {
+	Key   string
+	Value string
+}
+
+// A RuntimeProfile knows how a language runtime or application server
+// takes its options, which annotations it reads and how it is probed.
+type RuntimeProfile interface {
+	// Environment variables carrying the options of a RUNTIME config.
+	// target is the Target of the config, if any.
+	EnvVars(target string, opts []RuntimeOption) []corev1.EnvVar
+	// Files carrying the options of RUNTIME configs, keyed by path in
+	// the container.
+	Files(opts []RuntimeOption) map[string]string
+	// Annotations on the specification exposed as environment
+	// variables, keyed by annotation.
+	AnnotationEnvVars() map[string]string
+	// Default readiness and liveness probes for a component listening
+	// on port. Used when the specification has none.
+	Probes(port int32) (readiness *corev1.Probe, liveness *corev1.Probe)
+}
+
+// Registered runtime profiles keyed by name and aliases.
+var RuntimeProfiles = map[string]RuntimeProfile{
+	"jvm":         jvmProfile{},
+	"java":        jvmProfile{},
+	"liberty":     libertyProfile{},
+	"openliberty": libertyProfile{},
+	"wlp":         libertyProfile{},
+	"nodejs":      nodejsProfile{},
+	"node":        nodejsProfile{},
+	"python":      pythonProfile{},
+	"dotnet":      dotnetProfile{},
+}
+
+// Returns the runtime profile of a component and whether it was
+// explicitly selected with the runtime annotation or spec.type.
+// Specifications with the legacy Liberty features annotation get the
+// Liberty profile implicitly.
+func runtimeProfile(mg *metagraf.MetaGraf) (RuntimeProfile, bool) {
+	if name, ok := mg.Metadata.Annotations[RuntimeAnnotation]; ok {
+		if p, ok := RuntimeProfiles[strings.ToLower(name)]; ok {
+			return p, true
+		}
+		log.Warningf("Unknown runtime profile %v in annotation %v", name, RuntimeAnnotation)
+	}
+	if p, ok := RuntimeProfiles[strings.ToLower(mg.Spec.Type)]; ok {
+		return p, true
+	}
+	if len(mg.Metadata.Annotations[libertyFeaturesAnnotation]) > 0 {
+		return libertyProfile{}, false
+	}
+	return nil, false
+}
+
+// Options of a config section with values from input properties,
+// falling back to option defaults when no value is given.
+func runtimeOptions(conf *metagraf.Config, source string, mgp metagraf.MGProperties) []RuntimeOption {
+	var opts []RuntimeOption
+	for _, o := range conf.Options {
+		value := o.Default
+		if p, ok := mgp[source+"|"+o.Name]; ok && len(p.Value) > 0 {
+			value = p.Value
+		}
+		opts = append(opts, RuntimeOption{Key: o.Name, Value: value})
+	}
+	return opts
+}
+
+// Environment variables from JVM_SYS_PROP configs, RUNTIME configs
+// and annotations read by the runtime profile.
+func runtimeEnvVars(mg *metagraf.MetaGraf, mgp metagraf.MGProperties) []corev1.EnvVar {
+	envs := jvmSysPropEnvVars(mg, mgp)
+
+	profile, _ := runtimeProfile(mg)
+	configs := GetMetagrafConfigsByType(mg, RuntimeConfigType)
+	if profile == nil {
+		if len(configs) > 0 {
+			log.Warningf("%v has %v configs but no runtime profile, set spec.type or the %v annotation", Name(mg), RuntimeConfigType, RuntimeAnnotation)
+		}
+		return envs
+	}
+
+	for i := range configs {
+		envs = append(envs, profile.EnvVars(configs[i].Target, runtimeOptions(&configs[i], configs[i].Name, mgp))...)
+	}
+
+	annotations := profile.AnnotationEnvVars()
+	var keys []string
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v := mg.Metadata.Annotations[k]; len(v) > 0 {
+			envs = append(envs, corev1.EnvVar{Name: annotations[k], Value: v})
+		}
+	}
+	return envs
+}
+
+// Files rendered by the runtime profile from all RUNTIME configs.
+func runtimeFiles(mg *metagraf.MetaGraf, mgp metagraf.MGProperties) map[string]string {
+	profile, _ := runtimeProfile(mg)
+	if profile == nil {
+		return nil
+	}
+	var opts []RuntimeOption
+	configs := GetMetagrafConfigsByType(mg, RuntimeConfigType)
+	for i := range configs {
+		opts = append(opts, runtimeOptions(&configs[i], configs[i].Name, mgp)...)
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return profile.Files(opts)
+}
+
+func runtimeConfigMapName(mg *metagraf.MetaGraf) string {
+	return Name(mg) + "-runtime"
+}
+
+// ConfigMap holding the runtime files of a component, keyed by file
+// name. Returns false if the runtime profile renders no files.
+func genRuntimeConfigMap(mg *metagraf.MetaGraf, mgp metagraf.MGProperties) (corev1.ConfigMap, bool) {
+	files := runtimeFiles(mg, mgp)
+	if len(files) == 0 {
+		return corev1.ConfigMap{}, false
+	}
+
+	cm := corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   runtimeConfigMapName(mg),
+			Labels: Labels(Name(mg), labelsFromParams(params.Labels)),
+		},
+		Data: make(map[string]string),
+	}
+	for p, content := range files {
+		cm.Data[path.Base(p)] = content
+	}
+	return cm, true
+}
+
+func genRuntimeConfigMaps(mg *metagraf.MetaGraf) {
+	cm, ok := genRuntimeConfigMap(mg, Variables)
+	if !ok {
+		return
+	}
+	if !Dryrun {
+		StoreConfigMap(cm)
+	}
+	if Output {
+		MarshalObject(cm.DeepCopyObject())
+	}
+}
+
+// Mounts each runtime file from the runtime ConfigMap at its path.
+func runtimeMounts(mg *metagraf.MetaGraf) []Mount {
+	files := runtimeFiles(mg, Variables)
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var mounts []Mount
+	for _, p := range paths {
+		key := path.Base(p)
+		mounts = append(mounts, Mount{
+			Name:      runtimeConfigMapName(mg),
+			Type:      MountConfig,
+			Volume:    "runtime-" + volumeName(key),
+			MountPath: p,
+			SubPath:   key,
+			Mode:      defaultMountMode,
+		})
+	}
+	return mounts
+}
+
+// Sets the default probes of an explicitly selected runtime profile
+// on a container without probes of its own.
+func applyRuntimeProbes(mg *metagraf.MetaGraf, c *corev1.Container) {
+	profile, explicit := runtimeProfile(mg)
+	if !explicit || len(c.Ports) == 0 {
+		return
+	}
+	readiness, liveness := profile.Probes(c.Ports[0].ContainerPort)
+	if c.ReadinessProbe == nil {
+		c.ReadinessProbe = readiness
+	}
+	if c.LivenessProbe == nil {
+		c.LivenessProbe = liveness
+	}
+}
+
+func tcpProbe(port int32, delay int32) *corev1.Probe {
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(int(port))},
+		},
+		InitialDelaySeconds: delay,
+		PeriodSeconds:       10,
+	}
+}
+
+func httpProbe(port int32, path string, delay int32) *corev1.Probe {
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{Path: path, Port: intstr.FromInt(int(port))},
+		},
+		InitialDelaySeconds: delay,
+		PeriodSeconds:       10,
+	}
+}
+
+// Options as -Dkey=value system properties in JAVA_TOOL_OPTIONS.
+type jvmProfile struct{}
+
+func (jvmProfile) EnvVars(target string, opts []RuntimeOption) []corev1.EnvVar {
+	if len(target) == 0 {
+		target = "JAVA_TOOL_OPTIONS"
+	}
+	return []corev1.EnvVar{{Name: target, Value: jvmSysProps(opts)}}
+}
+
+func (jvmProfile) Files(opts []RuntimeOption) map[string]string {
+	return nil
+}
+
+func (jvmProfile) AnnotationEnvVars() map[string]string {
+	return nil
+}
+
+func (jvmProfile) Probes(port int32) (*corev1.Probe, *corev1.Probe) {
+	return tcpProbe(port, 10), tcpProbe(port, 60)
+}
+
+// Options as KEY=value lines in server.env, Liberty features from the
+// Liberty features annotation and MicroProfile Health probes.
+type libertyProfile struct{}
+
+const libertyFeaturesAnnotation = "norsk-tipping.no/libertyfeatures"
+
+func (libertyProfile) EnvVars(target string, opts []RuntimeOption) []corev1.EnvVar {
+	return nil
+}
+
+func (libertyProfile) Files(opts []RuntimeOption) map[string]string {
+	var lines []string
+	for _, o := range opts {
+		lines = append(lines, o.Key+"="+o.Value)
+	}
+	return map[string]string{"/config/server.env": strings.Join(lines, "\n") + "\n"}
+}
+
+func (libertyProfile) AnnotationEnvVars() map[string]string {
+	return map[string]string{libertyFeaturesAnnotation: "LIBERTY_FEATURES"}
+}
+
+func (libertyProfile) Probes(port int32) (*corev1.Probe, *corev1.Probe) {
+	return httpProbe(port, "/health/ready", 10), httpProbe(port, "/health/live", 60)
+}
+
+// Options as --key=value flags in NODE_OPTIONS. Options without a
+// value become plain --key flags.
+type nodejsProfile struct{}
+
+func (nodejsProfile) EnvVars(target string, opts []RuntimeOption) []corev1.EnvVar {
+	if len(target) == 0 {
+		target = "NODE_OPTIONS"
+	}
+	var flags []string
+	for _, o := range opts {
+		flag := "--" + strings.TrimLeft(o.Key, "-")
+		if len(o.Value) > 0 {
+			flag += "=" + o.Value
+		}
+		flags = append(flags, flag)
+	}
+	return []corev1.EnvVar{{Name: target, Value: strings.Join(flags, " ")}}
+}
+
+func (nodejsProfile) Files(opts []RuntimeOption) map[string]string {
+	return nil
+}
+
+func (nodejsProfile) AnnotationEnvVars() map[string]string {
+	return nil
+}
+
+func (nodejsProfile) Probes(port int32) (*corev1.Probe, *corev1.Probe) {
+	return tcpProbe(port, 5), tcpProbe(port, 30)
+}
+
+// Options as environment variables, like PYTHONUNBUFFERED.
+type pythonProfile struct{}
+
+func (pythonProfile) EnvVars(target string, opts []RuntimeOption) []corev1.EnvVar {
+	var envs []corev1.EnvVar
+	for _, o := range opts {
+		envs = append(envs, corev1.EnvVar{Name: o.Key, Value: o.Value})
+	}
+	return envs
+}
+
+func (pythonProfile) Files(opts []RuntimeOption) map[string]string {
+	return nil
+}
+
+func (pythonProfile) AnnotationEnvVars() map[string]string {
+	return nil
+}
+
+func (pythonProfile) Probes(port int32) (*corev1.Probe, *corev1.Probe) {
+	return tcpProbe(port, 5), tcpProbe(port, 30)
+}
+
+// Options as environment variables read by the .NET configuration
+// system, with : in keys replaced by __.
+type dotnetProfile struct{}
+
+func (dotnetProfile) EnvVars(target string, opts []RuntimeOption) []corev1.EnvVar {
+	var envs []corev1.EnvVar
+	for _, o := range opts {
+		envs = append(envs, corev1.EnvVar{Name: strings.Replace(o.Key, ":", "__", -1), Value: o.Value})
+	}
+	return envs
+}
+
+func (dotnetProfile) Files(opts []RuntimeOption) map[string]string {
+	return nil
+}
+
+func (dotnetProfile) AnnotationEnvVars() map[string]string {
+	return nil
+}
+
+func (dotnetProfile) Probes(port int32) (*corev1.Probe, *corev1.Probe) {
+	return tcpProbe(port, 5), tcpProbe(port, 30)
+}
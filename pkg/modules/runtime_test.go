@@ -0,0 +1,141 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestJVMSysPropTargets(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Spec.Environment.Local = []metagraf.EnvironmentVar{
+		{Name: "JAVA_OPTS", Type: "JVM_SYS_PROP"},
+	}
+	mg.Spec.Config = []metagraf.Config{
+		{Name: "JVM_SYS_PROP", Type: "JVM_SYS_PROP", Options: []metagraf.ConfigParam{{Name: "a", Default: "1"}}},
+		{Name: "agent", Type: "JVM_SYS_PROP", Target: "AGENT_OPTS", Options: []metagraf.ConfigParam{{Name: "b", Default: "2"}}},
+	}
+	mgp := metagraf.MGProperties{
+		"JVM_SYS_PROP|b": {Source: "JVM_SYS_PROP", Key: "b", Value: "3"},
+	}
+
+	envs := jvmSysPropEnvVars(&mg, mgp)
+	if len(envs) != 2 {
+		t.Fatalf("Expected 2 JVM_SYS_PROP targets, got %v", envs)
+	}
+	if envs[0].Name != "JAVA_OPTS" || envs[0].Value != "-Da=1" {
+		t.Errorf("Unexpected untargeted options %v", envs[0])
+	}
+	if envs[1].Name != "AGENT_OPTS" || envs[1].Value != "-Db=3" {
+		t.Errorf("Unexpected targeted options %v", envs[1])
+	}
+}
+
+func TestRuntimeProfiles(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Type = "nodejs"
+	mg.Spec.Config = []metagraf.Config{
+		{Name: "node", Type: RuntimeConfigType, Options: []metagraf.ConfigParam{
+			{Name: "max-old-space-size", Default: "512"},
+			{Name: "enable-source-maps"},
+		}},
+	}
+
+	envs := runtimeEnvVars(&mg, metagraf.MGProperties{})
+	if len(envs) != 1 || envs[0].Name != "NODE_OPTIONS" || envs[0].Value != "--max-old-space-size=512 --enable-source-maps" {
+		t.Errorf("Unexpected nodejs env vars %v", envs)
+	}
+
+	// The annotation takes precedence over spec.type.
+	mg.Metadata.Annotations = map[string]string{
+		RuntimeAnnotation:         "liberty",
+		libertyFeaturesAnnotation: "mpHealth-3.0",
+	}
+	envs = runtimeEnvVars(&mg, metagraf.MGProperties{})
+	if len(envs) != 1 || envs[0].Name != "LIBERTY_FEATURES" || envs[0].Value != "mpHealth-3.0" {
+		t.Errorf("Unexpected liberty env vars %v", envs)
+	}
+	cm, ok := genRuntimeConfigMap(&mg, metagraf.MGProperties{})
+	if !ok || cm.Name != "appv1-runtime" || cm.Data["server.env"] != "max-old-space-size=512\nenable-source-maps=\n" {
+		t.Errorf("Unexpected runtime ConfigMap %v", cm)
+	}
+	mounts := runtimeMounts(&mg)
+	if len(mounts) != 1 || mounts[0].MountPath != "/config/server.env" || mounts[0].SubPath != "server.env" {
+		t.Errorf("Unexpected runtime mounts %v", mounts)
+	}
+
+	c := corev1.Container{Ports: []corev1.ContainerPort{{ContainerPort: 9080}}}
+	applyRuntimeProbes(&mg, &c)
+	if c.ReadinessProbe == nil || c.ReadinessProbe.HTTPGet.Path != "/health/ready" || c.LivenessProbe.HTTPGet.Port.IntVal != 9080 {
+		t.Errorf("Unexpected liberty probes %v %v", c.ReadinessProbe, c.LivenessProbe)
+	}
+
+	// The legacy Liberty features annotation alone selects Liberty
+	// implicitly, without default probes.
+	delete(mg.Metadata.Annotations, RuntimeAnnotation)
+	mg.Spec.Type = "service"
+	c = corev1.Container{Ports: []corev1.ContainerPort{{ContainerPort: 9080}}}
+	applyRuntimeProbes(&mg, &c)
+	if c.ReadinessProbe != nil {
+		t.Errorf("Expected no probes without an explicit runtime profile, got %v", c.ReadinessProbe)
+	}
+	if _, ok := runtimeProfile(&mg); ok {
+		t.Errorf("Expected implicit runtime profile")
+	}
+
+	envs = dotnetProfile{}.EnvVars("", []RuntimeOption{{Key: "Logging:LogLevel:Microsoft.AspNetCore", Value: "Warning"}})
+	if envs[0].Name != "Logging__LogLevel__Microsoft.AspNetCore" {
+		t.Errorf("Unexpected dotnet env var %v", envs[0])
+	}
+}
+
+// Properties from the specification carry no values, the option
+// defaults apply until a value is given.
+func TestRuntimeOptionDefaults(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Type = "nodejs"
+	mg.Spec.Config = []metagraf.Config{
+		{Name: "node", Type: RuntimeConfigType, Options: []metagraf.ConfigParam{
+			{Name: "max-old-space-size", Default: "512"},
+		}},
+	}
+
+	envs := runtimeEnvVars(&mg, mg.GetProperties())
+	if len(envs) != 1 || envs[0].Value != "--max-old-space-size=512" {
+		t.Errorf("Expected the option default in NODE_OPTIONS, got %v", envs)
+	}
+
+	mg.Metadata.Annotations = map[string]string{RuntimeAnnotation: "liberty"}
+	cm, ok := genRuntimeConfigMap(&mg, mg.GetProperties())
+	if !ok || cm.Data["server.env"] != "max-old-space-size=512\n" {
+		t.Errorf("Expected the option default in server.env, got %v", cm.Data)
+	}
+}
+
+func TestRuntimeConfigTypeCase(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Type = "nodejs"
+	mg.Spec.Config = []metagraf.Config{
+		{Name: "node", Type: "runtime", Options: []metagraf.ConfigParam{{Name: "max-old-space-size", Default: "512"}}},
+	}
+
+	props := mg.GetProperties()
+	if _, ok := props["node|max-old-space-size"]; !ok {
+		t.Errorf("Expected a property for the lower case runtime config, got %v", props)
+	}
+	props["node|max-old-space-size"] = metagraf.MGProperty{Source: "node", Key: "max-old-space-size", Value: "1024"}
+	envs := runtimeEnvVars(&mg, props)
+	if len(envs) != 1 || envs[0].Value != "--max-old-space-size=1024" {
+		t.Errorf("Expected the input value in NODE_OPTIONS, got %v", envs)
+	}
+	if mounts := FindMetagrafConfigMaps(&mg); len(mounts) != 0 {
+		t.Errorf("Expected no ConfigMap mounts for a runtime config, got %v", mounts)
+	}
+}
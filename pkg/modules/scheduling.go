@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/affinity"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+	log "k8s.io/klog"
+)
+
+// Applies affinity, tolerations and topology spread constraints from
+// spec.scheduling to the pod spec of a workload. Anti-affinity from
+// --with-affinity-rules overrides the specification.
+func applyScheduling(mg *metagraf.MetaGraf, spec *corev1.PodSpec) {
+	objname := Name(mg)
+	s := mg.Spec.Scheduling
+	if s == nil {
+		s = &metagraf.Scheduling{}
+	}
+
+	aff := corev1.Affinity{}
+	if params.WithAffinityRules {
+		aff.PodAntiAffinity = affinity.SoftPodAntiAffinity(objname, params.PodAntiAffinityTopologyKey, params.PodAntiAffinityWeight).PodAntiAffinity
+	} else if s.AntiAffinity != nil {
+		aff.PodAntiAffinity = podAntiAffinity(objname, s.AntiAffinity)
+	}
+	if s.NodeAffinity != nil {
+		aff.NodeAffinity = affinity.NodeAffinity(s.NodeAffinity.Required, s.NodeAffinity.Preferred, weightOrDefault(s.NodeAffinity.Weight))
+	}
+	if aff.PodAntiAffinity != nil || aff.NodeAffinity != nil {
+		spec.Affinity = &aff
+	}
+
+	spec.Tolerations = s.Tolerations
+
+	for _, t := range s.TopologySpread {
+		if len(t.TopologyKey) == 0 {
+			log.Warningf("%v: topology spread without a topologyKey, skipping", objname)
+			continue
+		}
+		skew := t.MaxSkew
+		if skew == 0 {
+			skew = 1
+		}
+		when := t.WhenUnsatisfiable
+		if len(when) == 0 {
+			when = corev1.ScheduleAnyway
+		}
+		spec.TopologySpreadConstraints = append(spec.TopologySpreadConstraints, affinity.TopologySpreadConstraint(objname, t.TopologyKey, skew, when))
+	}
+}
+
+func podAntiAffinity(objname string, a *metagraf.AntiAffinity) *corev1.PodAntiAffinity {
+	if len(a.TopologyKey) == 0 {
+		log.Warningf("%v: anti-affinity without a topologyKey, skipping", objname)
+		return nil
+	}
+	switch strings.ToLower(a.Type) {
+	case "", "soft":
+		return affinity.SoftPodAntiAffinity(objname, a.TopologyKey, weightOrDefault(a.Weight)).PodAntiAffinity
+	case "hard":
+		return affinity.HardPodAntiAffinity(objname, a.TopologyKey).PodAntiAffinity
+	}
+	log.Warningf("%v: unknown anti-affinity type %v, use soft or hard", objname, a.Type)
+	return nil
+}
+
+func weightOrDefault(weight int32) int32 {
+	if weight == 0 {
+		return params.PodAntiAffinityWeightDefault
+	}
+	return weight
+}
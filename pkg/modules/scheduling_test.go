@@ -0,0 +1,74 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestApplyScheduling(t *testing.T) {
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+
+	spec := corev1.PodSpec{}
+	applyScheduling(&mg, &spec)
+	if spec.Affinity != nil || len(spec.TopologySpreadConstraints) != 0 {
+		t.Errorf("Expected no scheduling rules without spec.scheduling, got %v", spec)
+	}
+
+	mg.Spec.Scheduling = &metagraf.Scheduling{
+		AntiAffinity: &metagraf.AntiAffinity{Type: "hard", TopologyKey: "kubernetes.io/hostname"},
+		NodeAffinity: &metagraf.NodeAffinity{
+			Required:  map[string]string{"node-role.kubernetes.io/app": "true"},
+			Preferred: map[string]string{"pool": "fast"},
+		},
+		Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "app", Effect: corev1.TaintEffectNoSchedule}},
+		TopologySpread: []metagraf.TopologySpread{
+			{TopologyKey: "topology.kubernetes.io/zone"},
+			{TopologyKey: "kubernetes.io/hostname", MaxSkew: 2, WhenUnsatisfiable: corev1.DoNotSchedule},
+		},
+	}
+	spec = corev1.PodSpec{}
+	applyScheduling(&mg, &spec)
+
+	anti := spec.Affinity.PodAntiAffinity
+	if len(anti.RequiredDuringSchedulingIgnoredDuringExecution) != 1 || anti.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey != "kubernetes.io/hostname" {
+		t.Errorf("Expected hard anti-affinity, got %v", anti)
+	}
+	node := spec.Affinity.NodeAffinity
+	if node.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Key != "node-role.kubernetes.io/app" {
+		t.Errorf("Unexpected required node affinity %v", node.RequiredDuringSchedulingIgnoredDuringExecution)
+	}
+	if node.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight != params.PodAntiAffinityWeightDefault {
+		t.Errorf("Expected default weight on preferred node affinity, got %v", node.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+	if len(spec.Tolerations) != 1 {
+		t.Errorf("Expected tolerations from the specification, got %v", spec.Tolerations)
+	}
+	tsc := spec.TopologySpreadConstraints
+	if len(tsc) != 2 || tsc[0].MaxSkew != 1 || tsc[0].WhenUnsatisfiable != corev1.ScheduleAnyway || tsc[1].WhenUnsatisfiable != corev1.DoNotSchedule {
+		t.Errorf("Unexpected topology spread constraints %v", tsc)
+	}
+	if tsc[0].LabelSelector.MatchExpressions[0].Values[0] != "appv1" {
+		t.Errorf("Expected topology spread to select the pods of the component, got %v", tsc[0].LabelSelector)
+	}
+
+	// The CLI flags override anti-affinity from the specification.
+	params.WithAffinityRules = true
+	params.PodAntiAffinityTopologyKey = "topology.kubernetes.io/zone"
+	params.PodAntiAffinityWeight = 50
+	defer func() {
+		params.WithAffinityRules = false
+		params.PodAntiAffinityTopologyKey = ""
+		params.PodAntiAffinityWeight = 0
+	}()
+	spec = corev1.PodSpec{}
+	applyScheduling(&mg, &spec)
+	anti = spec.Affinity.PodAntiAffinity
+	if len(anti.RequiredDuringSchedulingIgnoredDuringExecution) != 0 || anti.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight != 50 {
+		t.Errorf("Expected soft anti-affinity from flags, got %v", anti)
+	}
+}
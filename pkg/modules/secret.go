@@ -20,10 +20,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 
 	k8sclient "github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/laetho/metagraf/pkg/metagraf"
 	log "k8s.io/klog"
 
@@ -31,20 +31,43 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func FindSecrets(mg *metagraf.MetaGraf) map[string]string {
-	maps := make(map[string]string)
+// Returns the Secrets to mount into the pod of a component.
+func FindSecrets(mg *metagraf.MetaGraf) []Mount {
+	var mounts []Mount
 
 	for _, r := range mg.Spec.Resources {
-		if len(r.User) > 0 {
-			maps[strings.ToLower(strings.Replace(r.Name, "_", "-", -1))+"-"+strings.ToLower(r.User)] = "password"
+		if len(r.User) > 0 || len(r.Secret) > 0 {
+			n := ResourceSecretName(&r)
+			mounts = appendMount(mounts, Mount{
+				Name:      n,
+				Type:      MountSecret,
+				Volume:    volumeName(n),
+				MountPath: "/mg/secret/" + n,
+				Mode:      defaultMountMode,
+			})
 		}
 	}
 
 	for _, s := range mg.Spec.Secret {
-		maps[strings.ToLower(s.Name)] = strconv.FormatBool(s.Global)
+		n := strings.ToLower(s.Name)
+		name := secretName(&s, mg)
+		if params.LegacyMounts {
+			// Secrets named without the component prefix, as mounted
+			// before mg generated spec.secret Secrets.
+			name = n
+		}
+		m := Mount{
+			Name:      name,
+			Type:      MountSecret,
+			Volume:    volumeName(n),
+			MountPath: "/mg/secret/" + n,
+			Mode:      defaultMountMode,
+		}
+		m.apply(s.MountPath, s.SubPath, s.Items, s.Mode)
+		mounts = appendMount(mounts, m)
 	}
 
-	return maps
+	return mounts
 }
 
 func GenSecrets(mg *metagraf.MetaGraf) {
@@ -56,12 +79,7 @@ func GenSecrets(mg *metagraf.MetaGraf) {
 				labels["app"] = strings.ToLower(mg.Name(OName,Version))
 
 				obj := CreateEmptySecret(e.SecretFrom,labels)
-				if !Dryrun {
-					StoreSecret(obj)
-				}
-				if Output {
-					MarshalObject(obj.DeepCopyObject())
-				}
+				emitSecret(obj, "")
 			} else {
 				continue
 			}
@@ -84,12 +102,7 @@ func GenSecrets(mg *metagraf.MetaGraf) {
 		}
 
 		obj := genResourceSecret(&r, mg)
-		if !Dryrun {
-			StoreSecret(*obj)
-		}
-		if Output {
-			MarshalObject(obj.DeepCopyObject())
-		}
+		emitSecret(*obj, "")
 	}
 
 	for _, s := range mg.Spec.Secret {
@@ -99,18 +112,14 @@ func GenSecrets(mg *metagraf.MetaGraf) {
 			continue
 		}
 
-		if secretExists(strings.ToLower(s.Name)) {
-			log.Info("Skipping secret: ", Name(mg)+"-"+strings.ToLower(s.Name))
+		// Never overwrite existing, possibly generated, values.
+		if secretExists(secretName(&s, mg)) {
+			log.Info("Skipping secret: ", secretName(&s, mg))
 			continue
 		}
 
 		obj := genSecret(&s, mg)
-		if !Dryrun {
-			StoreSecret(*obj)
-		}
-		if Output {
-			MarshalObject(obj.DeepCopyObject())
-		}
+		emitSecret(*obj, s.ExternalKey)
 	}
 }
 
@@ -136,17 +145,18 @@ func GetSecret(name string) (*corev1.Secret, error) {
 	return sec, nil
 }
 
-func genSecret(s *metagraf.Secret, mg *metagraf.MetaGraf) *corev1.Secret {
-	objname := Name(mg)
+// Name of the Secret generated for a spec.secret entry.
+func secretName(s *metagraf.Secret, mg *metagraf.MetaGraf) string {
+	if s.Global == true {
+		return strings.ToLower(s.Name)
+	}
+	return Name(mg) + "-" + strings.ToLower(s.Name)
+}
 
+func genSecret(s *metagraf.Secret, mg *metagraf.MetaGraf) *corev1.Secret {
 	// Resource labels
 	l := make(map[string]string)
-
-	if s.Global == true {
-		l["name"] = strings.ToLower(s.Name)
-	} else {
-		l["name"] = objname + "-" + strings.ToLower(s.Name)
-	}
+	l["name"] = secretName(s, mg)
 
 	// Populate v1.Secret StringData and Data
 	stringdata := make(map[string]string)
@@ -169,6 +179,17 @@ func genSecret(s *metagraf.Secret, mg *metagraf.MetaGraf) *corev1.Secret {
 		Data:       data,
 	}
 
+	if s.Generator != nil {
+		t, data, err := generateSecretData(mg, s.Generator)
+		if err != nil {
+			log.Errorf("Unable to generate secret %v: %v", sec.Name, err)
+			os.Exit(1)
+		}
+		sec.Type = t
+		sec.Data = data
+		sec.StringData = nil
+	}
+
 	return &sec
 }
 
@@ -192,7 +213,12 @@ func genResourceSecret(res *metagraf.Resource, mg *metagraf.MetaGraf) *corev1.Se
 		stringdata["type"] = res.Type
 		stringdata["templateref"] = res.TemplateRef
 		stringdata["user"] = res.User
-		stringdata["password"] = "replaceme"
+		password, err := randomString(32, charsetAlphanumeric)
+		if err != nil {
+			log.Errorf("Unable to generate password for resource %v: %v", res.Name, err)
+			os.Exit(1)
+		}
+		stringdata["password"] = password
 	}
 
 	//if len(res.Secret) > 0 && res.SecretType == "cert" {
@@ -0,0 +1,334 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/laetho/metagraf/internal/pkg/k8sclient"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	log "k8s.io/klog"
+)
+
+// Formats of generated Secrets, selected with params.SecretFormat.
+const (
+	SecretFormatPlain    = "plain"
+	SecretFormatSealed   = "sealed"
+	SecretFormatExternal = "external"
+)
+
+var (
+	sealedSecretResource   = schema.GroupVersionResource{Group: "bitnami.com", Version: "v1alpha1", Resource: "sealedsecrets"}
+	externalSecretResource = schema.GroupVersionResource{Group: "external-secrets.io", Version: "v1beta1", Resource: "externalsecrets"}
+)
+
+// Bitnami SealedSecret, a Secret encrypted for the sealed-secrets controller.
+type SealedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              SealedSecretSpec `json:"spec"`
+}
+
+type SealedSecretSpec struct {
+	EncryptedData map[string]string    `json:"encryptedData"`
+	Template      SealedSecretTemplate `json:"template"`
+}
+
+type SealedSecretTemplate struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Type              corev1.SecretType `json:"type,omitempty"`
+}
+
+// ExternalSecret from the external-secrets operator, a Secret read from
+// an external secret store.
+type ExternalSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ExternalSecretSpec `json:"spec"`
+}
+
+type ExternalSecretSpec struct {
+	RefreshInterval string                 `json:"refreshInterval,omitempty"`
+	SecretStoreRef  SecretStoreRef         `json:"secretStoreRef"`
+	Target          ExternalSecretTarget   `json:"target"`
+	DataFrom        []ExternalSecretSource `json:"dataFrom"`
+}
+
+type SecretStoreRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+type ExternalSecretTarget struct {
+	Name     string                        `json:"name"`
+	Template *ExternalSecretTargetTemplate `json:"template,omitempty"`
+}
+
+type ExternalSecretTargetTemplate struct {
+	Type     corev1.SecretType `json:"type,omitempty"`
+	Metadata metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+type ExternalSecretSource struct {
+	Extract ExternalSecretKey `json:"extract"`
+}
+
+type ExternalSecretKey struct {
+	Key string `json:"key"`
+}
+
+// Stores and outputs a generated Secret in the format selected by
+// params.SecretFormat. externalKey overrides the conventional key of
+// the secret in an external store.
+func emitSecret(obj corev1.Secret, externalKey string) {
+	switch params.SecretFormat {
+	case SecretFormatPlain, "":
+		if !Dryrun {
+			StoreSecret(obj)
+		}
+		if Output {
+			MarshalObject(obj.DeepCopyObject())
+		}
+		return
+	case SecretFormatSealed:
+		cert, err := readSealingKey(params.SealedSecretsCert)
+		if err != nil {
+			log.Errorf("Unable to read sealed-secrets certificate: %v", err)
+			os.Exit(1)
+		}
+		sealed, err := sealSecret(obj, cert)
+		if err != nil {
+			log.Errorf("Unable to seal Secret %v: %v", obj.Name, err)
+			os.Exit(1)
+		}
+		storeOrOutput(sealed, sealed.Name, sealedSecretResource)
+		return
+	case SecretFormatExternal:
+		es, err := externalSecret(obj, externalKey)
+		if err != nil {
+			log.Errorf("Unable to generate ExternalSecret %v: %v", obj.Name, err)
+			os.Exit(1)
+		}
+		storeOrOutput(es, es.Name, externalSecretResource)
+		return
+	}
+	log.Errorf("Unknown secret format %v, use plain, sealed or external.", params.SecretFormat)
+	os.Exit(1)
+}
+
+// Reads the RSA public key from a PEM encoded certificate.
+func readSealingKey(file string) (*rsa.PublicKey, error) {
+	if len(file) == 0 {
+		return nil, errors.New("no certificate provided")
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%v is not a PEM encoded certificate", file)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%v does not hold an RSA public key", file)
+	}
+	return key, nil
+}
+
+// Encrypts the data of a Secret the way kubeseal does with strict scope,
+// so it can only be decrypted into a Secret with the same name and
+// namespace.
+func sealSecret(obj corev1.Secret, key *rsa.PublicKey) (SealedSecret, error) {
+	namespace := obj.Namespace
+	if len(namespace) == 0 {
+		namespace = NameSpace
+	}
+	if len(namespace) == 0 {
+		return SealedSecret{}, errors.New("a namespace is required for sealing secrets")
+	}
+
+	data := make(map[string][]byte)
+	for k, v := range obj.Data {
+		data[k] = v
+	}
+	for k, v := range obj.StringData {
+		data[k] = []byte(v)
+	}
+
+	label := []byte(namespace + "/" + obj.Name)
+	encrypted := make(map[string]string)
+	for k, v := range data {
+		ciphertext, err := hybridEncrypt(key, v, label)
+		if err != nil {
+			return SealedSecret{}, err
+		}
+		encrypted[k] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	return SealedSecret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "SealedSecret",
+			APIVersion: "bitnami.com/v1alpha1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obj.Name,
+			Namespace: namespace,
+			Labels:    obj.Labels,
+		},
+		Spec: SealedSecretSpec{
+			EncryptedData: encrypted,
+			Template: SealedSecretTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      obj.Name,
+					Namespace: namespace,
+					Labels:    obj.Labels,
+				},
+				Type: obj.Type,
+			},
+		},
+	}, nil
+}
+
+// RSA-OAEP encrypts a random session key, which AES-GCM encrypts the
+// plaintext. Output is the length of the encrypted session key as two
+// bytes, the encrypted session key and the encrypted plaintext.
+func hybridEncrypt(key *rsa.PublicKey, plaintext []byte, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, 32)
+	_, err := rand.Read(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, sessionKey, label)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// The session key is only used once, a zero nonce is safe.
+	nonce := make([]byte, aed.NonceSize())
+
+	out := make([]byte, 2, 2+len(encryptedKey)+len(plaintext)+aed.Overhead())
+	binary.BigEndian.PutUint16(out, uint16(len(encryptedKey)))
+	out = append(out, encryptedKey...)
+	return aed.Seal(out, nonce, plaintext, nil), nil
+}
+
+// Generates an ExternalSecret producing a Secret with the name, labels
+// and type of obj from a key in params.ExternalSecretStore.
+func externalSecret(obj corev1.Secret, key string) (ExternalSecret, error) {
+	if len(params.ExternalSecretStore) == 0 {
+		return ExternalSecret{}, errors.New("no secret store provided")
+	}
+	if len(key) == 0 {
+		key = strings.TrimPrefix(NameSpace+"/"+obj.Name, "/")
+	}
+
+	return ExternalSecret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ExternalSecret",
+			APIVersion: "external-secrets.io/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   obj.Name,
+			Labels: obj.Labels,
+		},
+		Spec: ExternalSecretSpec{
+			RefreshInterval: "1h",
+			SecretStoreRef: SecretStoreRef{
+				Name: params.ExternalSecretStore,
+				Kind: params.ExternalSecretStoreKind,
+			},
+			Target: ExternalSecretTarget{
+				Name: obj.Name,
+				Template: &ExternalSecretTargetTemplate{
+					Type:     obj.Type,
+					Metadata: metav1.ObjectMeta{Labels: obj.Labels},
+				},
+			},
+			DataFrom: []ExternalSecretSource{
+				{Extract: ExternalSecretKey{Key: key}},
+			},
+		},
+	}, nil
+}
+
+// Stores obj as a resource of the custom resource gvr unless Dryrun, and
+// outputs it if Output.
+func storeOrOutput(obj interface{}, name string, gvr schema.GroupVersionResource) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	u := &unstructured.Unstructured{}
+	err = u.UnmarshalJSON(b)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	if !Dryrun {
+		client := k8sclient.GetDynamicClient().Resource(gvr).Namespace(NameSpace)
+		res, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+		if err == nil {
+			u.SetResourceVersion(res.GetResourceVersion())
+			_, err = client.Update(context.TODO(), u, metav1.UpdateOptions{})
+		} else {
+			_, err = client.Create(context.TODO(), u, metav1.CreateOptions{})
+		}
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		fmt.Println("Stored ", u.GetKind(), ": ", name, " in Namespace: ", NameSpace)
+	}
+	if Output {
+		MarshalObject(u)
+	}
+}
@@ -0,0 +1,98 @@
+package modules
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	"github.com/laetho/metagraf/internal/pkg/params"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reverses hybridEncrypt, like the sealed-secrets controller does.
+func hybridDecrypt(t *testing.T, key *rsa.PrivateKey, ciphertext []byte, label []byte) []byte {
+	n := int(binary.BigEndian.Uint16(ciphertext))
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, ciphertext[2:2+n], label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := aed.Open(nil, make([]byte, aed.NonceSize()), ciphertext[2+n:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plaintext
+}
+
+func TestSealSecret(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "appv1-db", Namespace: "test"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"password": []byte("s3cret")},
+		StringData: map[string]string{"user": "app"},
+	}
+	sealed, err := sealSecret(obj, &key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.Name != "appv1-db" || sealed.Spec.Template.Type != corev1.SecretTypeOpaque {
+		t.Errorf("Unexpected SealedSecret %v, type %v", sealed.Name, sealed.Spec.Template.Type)
+	}
+
+	for k, want := range map[string]string{"password": "s3cret", "user": "app"} {
+		b, err := base64.StdEncoding.DecodeString(sealed.Spec.EncryptedData[k])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(hybridDecrypt(t, key, b, []byte("test/appv1-db"))); got != want {
+			t.Errorf("Expected %v for %v, got %v", want, k, got)
+		}
+	}
+}
+
+func TestExternalSecret(t *testing.T) {
+	NameSpace = "test"
+	params.ExternalSecretStore = "vault"
+	defer func() {
+		NameSpace = ""
+		params.ExternalSecretStore = ""
+	}()
+
+	obj := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "appv1-db"},
+		Type:       corev1.SecretTypeTLS,
+	}
+	es, err := externalSecret(obj, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es.Spec.DataFrom[0].Extract.Key != "test/appv1-db" || es.Spec.Target.Name != "appv1-db" || es.Spec.SecretStoreRef.Name != "vault" {
+		t.Errorf("Unexpected ExternalSecret %+v", es.Spec)
+	}
+	if es.Spec.Target.Template.Type != corev1.SecretTypeTLS {
+		t.Errorf("Expected type %v, got %v", corev1.SecretTypeTLS, es.Spec.Target.Template.Type)
+	}
+
+	es, err = externalSecret(obj, "apps/db")
+	if err != nil || es.Spec.DataFrom[0].Extract.Key != "apps/db" {
+		t.Errorf("Expected explicit key apps/db, got %v, %v", es.Spec.DataFrom, err)
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2021 The metaGraf Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modules
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/laetho/metagraf/internal/pkg/helpers"
+	"github.com/laetho/metagraf/internal/pkg/params"
+	"github.com/laetho/metagraf/pkg/metagraf"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Character sets for random values.
+const (
+	charsetAlphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	charsetHex          = "0123456789abcdef"
+	charsetASCII        = charsetAlphanumeric + "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+)
+
+// Key of the public key in generated ssh-auth Secrets.
+const sshPublicKey = "ssh-publickey"
+
+// Generates the type and data of a Secret from its generator.
+func generateSecretData(mg *metagraf.MetaGraf, g *metagraf.SecretGenerator) (corev1.SecretType, map[string][]byte, error) {
+	data := make(map[string][]byte)
+
+	switch g.Type {
+	case metagraf.SecretGeneratorRandom:
+		key := g.Key
+		if len(key) == 0 {
+			key = "password"
+		}
+		length := g.Length
+		if length == 0 {
+			length = 32
+		}
+		value, err := randomString(length, charset(g.Charset))
+		if err != nil {
+			return "", nil, err
+		}
+		data[key] = []byte(value)
+		return corev1.SecretTypeOpaque, data, nil
+
+	case metagraf.SecretGeneratorTLS:
+		bits := g.Bits
+		if bits == 0 {
+			bits = 2048
+		}
+		days := g.ValidDays
+		if days == 0 {
+			days = 365
+		}
+		cert, key, err := selfSignedCertificate(secretTLSHosts(mg, g), days, bits)
+		if err != nil {
+			return "", nil, err
+		}
+		data[corev1.TLSCertKey] = cert
+		data[corev1.TLSPrivateKeyKey] = key
+		return corev1.SecretTypeTLS, data, nil
+
+	case metagraf.SecretGeneratorSSH:
+		bits := g.Bits
+		if bits == 0 {
+			bits = 4096
+		}
+		private, public, err := sshKeyPair(bits)
+		if err != nil {
+			return "", nil, err
+		}
+		data[corev1.SSHAuthPrivateKey] = private
+		data[sshPublicKey] = public
+		return corev1.SecretTypeSSHAuth, data, nil
+
+	case metagraf.SecretGeneratorDockerConfig:
+		data[corev1.DockerConfigJsonKey] = []byte(`{"auths":{}}`)
+		return corev1.SecretTypeDockerConfigJson, data, nil
+	}
+	return "", nil, fmt.Errorf("unknown secret generator %v, use random, tls, ssh or dockerconfig", g.Type)
+}
+
+func charset(name string) string {
+	switch name {
+	case "", "alphanumeric":
+		return charsetAlphanumeric
+	case "hex":
+		return charsetHex
+	case "ascii":
+		return charsetASCII
+	}
+	return name
+}
+
+// Returns a random string of length characters from chars.
+func randomString(length int, chars string) (string, error) {
+	max := big.NewInt(int64(len(chars)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = chars[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// Hostnames of the component for a tls certificate. The Service names,
+// the Route hosts and the hosts of the generator.
+func secretTLSHosts(mg *metagraf.MetaGraf, g *metagraf.SecretGenerator) []string {
+	name := Name(mg)
+	hosts := []string{name}
+	if len(NameSpace) > 0 {
+		hosts = append(hosts,
+			name+"."+NameSpace,
+			name+"."+NameSpace+".svc",
+			name+"."+NameSpace+".svc.cluster.local",
+		)
+	}
+
+	if len(params.RouteHost) > 0 {
+		hosts = append(hosts, params.RouteHost)
+	}
+	if mg.Spec.Route != nil {
+		if len(mg.Spec.Route.Host) > 0 {
+			hosts = append(hosts, mg.Spec.Route.Host)
+		}
+		for _, p := range mg.Spec.Route.Paths {
+			if len(p.Host) > 0 {
+				hosts = append(hosts, p.Host)
+			}
+		}
+	}
+	hosts = append(hosts, g.Hosts...)
+
+	var out []string
+	for _, h := range hosts {
+		if !helpers.StringInSlice(h, out) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// Returns a PEM encoded self-signed certificate for hosts and its key.
+func selfSignedCertificate(hosts []string, days int, bits int) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	tmpl := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: hosts[0]},
+		NotBefore:             now,
+		NotAfter:              now.AddDate(0, 0, days),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	pkey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return cert, pkey, nil
+}
+
+// Returns a PEM encoded RSA private key and its public key in
+// authorized_keys format.
+func sshKeyPair(bits int) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return private, ssh.MarshalAuthorizedKey(pub), nil
+}
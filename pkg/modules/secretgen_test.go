@@ -0,0 +1,60 @@
+package modules
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateSecretData(t *testing.T) {
+	NameSpace = "test"
+	defer func() { NameSpace = "" }()
+
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+	mg.Spec.Route = &metagraf.Route{Host: "app.example.com"}
+
+	typ, data, err := generateSecretData(&mg, &metagraf.SecretGenerator{Type: "random", Key: "token", Length: 20, Charset: "hex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != corev1.SecretTypeOpaque || len(data["token"]) != 20 || strings.Trim(string(data["token"]), charsetHex) != "" {
+		t.Errorf("Unexpected random secret %v: %q", typ, data["token"])
+	}
+
+	typ, data, err = generateSecretData(&mg, &metagraf.SecretGenerator{Type: "tls", Bits: 1024, Hosts: []string{"10.0.0.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != corev1.SecretTypeTLS || len(data[corev1.TLSPrivateKeyKey]) == 0 {
+		t.Fatalf("Unexpected tls secret %v", typ)
+	}
+	block, _ := pem.Decode(data[corev1.TLSCertKey])
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range []string{"appv1", "appv1.test.svc", "app.example.com", "10.0.0.1"} {
+		if err := cert.VerifyHostname(h); err != nil {
+			t.Errorf("Certificate not valid for %v: %v", h, err)
+		}
+	}
+
+	typ, data, err = generateSecretData(&mg, &metagraf.SecretGenerator{Type: "ssh", Bits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != corev1.SecretTypeSSHAuth || !strings.HasPrefix(string(data[sshPublicKey]), "ssh-rsa ") || len(data[corev1.SSHAuthPrivateKey]) == 0 {
+		t.Errorf("Unexpected ssh secret %v", typ)
+	}
+
+	_, _, err = generateSecretData(&mg, &metagraf.SecretGenerator{Type: "unknown"})
+	if err == nil {
+		t.Error("Expected an error for an unknown generator")
+	}
+}
@@ -21,8 +21,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/laetho/metagraf/internal/pkg/helpers"
-	"github.com/laetho/metagraf/internal/pkg/imageurl"
 	"github.com/laetho/metagraf/internal/pkg/k8sclient"
 	"github.com/laetho/metagraf/internal/pkg/params"
 	"github.com/laetho/metagraf/pkg/metagraf"
@@ -33,47 +31,57 @@ import (
 )
 
 func GenService(mg *metagraf.MetaGraf) {
-	objname := Name(mg)
+	obj := genService(mg)
 
-	var DockerImage string
-	if len(mg.Spec.BaseRunImage) > 0 {
-		DockerImage = mg.Spec.BaseRunImage
-	} else if len(mg.Spec.BuildImage) > 0 {
-		DockerImage = mg.Spec.BuildImage
-	} else {
-		DockerImage = mg.Spec.Image
+	if !Dryrun {
+		StoreService(obj)
+	}
+	if Output {
+		MarshalObject(obj.DeepCopyObject())
 	}
 
-	var imgurl imageurl.ImageURL
-	_ = imgurl.Parse(DockerImage)
+	// Services addressing attached resources.
+	GenResourceServices(mg)
 
-	// ImageInfo := helpers.SkopeoImageInfo(DockerImage)
-	HasImageInfo := false
-	ImageInfo, err := helpers.ImageInfo(mg)
-	if err != nil {
-		HasImageInfo = false
-	} else {
-		HasImageInfo = true
+	// Optinonally also create a ServiceMonitor resource.
+	if params.ServiceMonitor {
+		if Output && Format == "yaml" {
+			fmt.Println("---")
+		}
+		GenServiceMonitor(mg)
 	}
 
-	if HasImageInfo {
-		client := k8sclient.GetImageClient()
-		ist := helpers.GetImageStreamTags(
-			client,
-			imgurl.Namespace,
-			imgurl.Image+":"+imgurl.Tag)
-		ImageInfo = helpers.GetDockerImageFromIST(ist)
+	// Optionally also create a PrometheusRule resource.
+	if params.PrometheusRule {
+		if Output && Format == "yaml" {
+			fmt.Println("---")
+		}
+		GenPrometheusRule(mg)
 	}
+}
+
+func genService(mg *metagraf.MetaGraf) corev1.Service {
+	objname := Name(mg)
+
+	ImageInfo, HasImageInfo := mgImageInfo(mg)
 
 	var serviceports []corev1.ServicePort
 	if HasImageInfo {
-		serviceports = GetServicePorts(mg, helpers.ImageExposedPortsToServicePorts(ImageInfo.Config))
+		serviceports = GetServicePorts(mg, ImageInfo.ServicePorts())
 	} else {
 		var ports []corev1.ServicePort
 		serviceports = GetServicePorts(mg, ports)
 	}
 
+	spec := metagraf.Service{}
+	if mg.Spec.Service != nil {
+		spec = *mg.Spec.Service
+	}
+
 	selectors := make(map[string]string)
+	for k, v := range spec.Selector {
+		selectors[k] = v
+	}
 	selectors["app"] = objname
 
 	labels := Labels(objname, labelsFromParams(params.Labels))
@@ -84,31 +92,57 @@ func GenService(mg *metagraf.MetaGraf) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   objname,
-			Labels: labels,
+			Name:        objname,
+			Labels:      labels,
+			Annotations: spec.Annotations,
 		},
 		Spec: corev1.ServiceSpec{
-			Ports:           serviceports,
-			Selector:        selectors,
-			Type:            "ClusterIP",
-			SessionAffinity: "None",
+			Ports:                    serviceports,
+			Selector:                 selectors,
+			Type:                     corev1.ServiceTypeClusterIP,
+			SessionAffinity:          corev1.ServiceAffinityNone,
+			PublishNotReadyAddresses: spec.PublishNotReadyAddresses,
 		},
 	}
 
-	if !Dryrun {
-		StoreService(obj)
+	if len(spec.Type) > 0 {
+		obj.Spec.Type = spec.Type
 	}
-	if Output {
-		MarshalObject(obj.DeepCopyObject())
+	switch obj.Spec.Type {
+	case corev1.ServiceTypeClusterIP:
+		if len(spec.ExternalTrafficPolicy) > 0 || len(spec.NodePorts) > 0 {
+			log.Warning("externalTrafficPolicy and nodePorts are only used with NodePort and LoadBalancer Services.")
+		}
+	case corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		obj.Spec.ExternalTrafficPolicy = spec.ExternalTrafficPolicy
+		for i, p := range obj.Spec.Ports {
+			obj.Spec.Ports[i].NodePort = spec.NodePorts[p.Name]
+		}
+	default:
+		log.Errorf("Unsupported Service type %v, use ClusterIP, NodePort or LoadBalancer.", spec.Type)
+		os.Exit(1)
+	}
+	if obj.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		obj.Spec.LoadBalancerSourceRanges = spec.LoadBalancerSourceRanges
 	}
 
-	// Optinonally also create a ServiceMonitor resource.
-	if params.ServiceMonitor {
-		if Output && Format == "yaml" {
-			fmt.Println("---")
+	if spec.Headless {
+		if obj.Spec.Type != corev1.ServiceTypeClusterIP {
+			log.Errorf("A headless Service must be of type ClusterIP, not %v.", obj.Spec.Type)
+			os.Exit(1)
 		}
-		GenServiceMonitor(mg)
+		obj.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+
+	if len(spec.SessionAffinity) > 0 {
+		obj.Spec.SessionAffinity = spec.SessionAffinity
 	}
+	if obj.Spec.SessionAffinity == corev1.ServiceAffinityClientIP && spec.SessionAffinityTimeout != nil {
+		obj.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+			ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: spec.SessionAffinityTimeout},
+		}
+	}
+	return obj
 }
 
 // Applies protocol and port conventions for generating standardized Kubernetes Service
@@ -173,6 +207,7 @@ func StoreService(obj corev1.Service) {
 	if len(svc.ResourceVersion) > 0 {
 		obj.ResourceVersion = svc.ResourceVersion
 		obj.Spec.ClusterIP = svc.Spec.ClusterIP
+		keepNodePorts(&obj, svc)
 		_, err := client.Update(context.TODO(), &obj, metav1.UpdateOptions{})
 		if err != nil {
 			log.Error(err)
@@ -191,6 +226,23 @@ func StoreService(obj corev1.Service) {
 	}
 }
 
+// Keeps node ports already allocated to the existing Service, unless
+// explicitly set, so updates do not move them.
+func keepNodePorts(obj *corev1.Service, existing *corev1.Service) {
+	if obj.Spec.Type != corev1.ServiceTypeNodePort && obj.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return
+	}
+	allocated := make(map[string]int32)
+	for _, p := range existing.Spec.Ports {
+		allocated[p.Name] = p.NodePort
+	}
+	for i, p := range obj.Spec.Ports {
+		if p.NodePort == 0 {
+			obj.Spec.Ports[i].NodePort = allocated[p.Name]
+		}
+	}
+}
+
 func DeleteService(name string) {
 	client := k8sclient.GetCoreClient().Services(NameSpace)
 
@@ -2,6 +2,9 @@ package modules
 
 import (
 	"testing"
+
+	"github.com/laetho/metagraf/pkg/metagraf"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestDefaultServicePorts(t *testing.T) {
@@ -46,3 +49,60 @@ func TestDefaultServicePorts(t *testing.T) {
 		}
 	})
 }
+
+func TestGenServiceSpec(t *testing.T) {
+	var timeout int32 = 600
+	mg := metagraf.MetaGraf{}
+	mg.Metadata.Name = "app"
+	mg.Spec.Version = "1.0.0"
+
+	obj := genService(&mg)
+	if obj.Spec.Type != corev1.ServiceTypeClusterIP || obj.Spec.SessionAffinity != corev1.ServiceAffinityNone {
+		t.Errorf("Expected ClusterIP Service without session affinity, got %v, %v", obj.Spec.Type, obj.Spec.SessionAffinity)
+	}
+
+	mg.Spec.Service = &metagraf.Service{
+		Type:                   corev1.ServiceTypeLoadBalancer,
+		ExternalTrafficPolicy:  corev1.ServiceExternalTrafficPolicyTypeLocal,
+		SessionAffinity:        corev1.ServiceAffinityClientIP,
+		SessionAffinityTimeout: &timeout,
+		NodePorts:              map[string]int32{"http": 30080},
+		Selector:               map[string]string{"tier": "web"},
+		Annotations:            map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+	}
+	obj = genService(&mg)
+	if obj.Spec.Type != corev1.ServiceTypeLoadBalancer || obj.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal {
+		t.Errorf("Unexpected type %v or traffic policy %v", obj.Spec.Type, obj.Spec.ExternalTrafficPolicy)
+	}
+	if obj.Spec.SessionAffinityConfig == nil || *obj.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds != timeout {
+		t.Errorf("Expected session affinity timeout %v, got %v", timeout, obj.Spec.SessionAffinityConfig)
+	}
+	if obj.Spec.Ports[0].NodePort != 30080 {
+		t.Errorf("Expected node port 30080, got %v", obj.Spec.Ports[0].NodePort)
+	}
+	if obj.Spec.Selector["app"] != "appv1" || obj.Spec.Selector["tier"] != "web" {
+		t.Errorf("Unexpected selector %v", obj.Spec.Selector)
+	}
+	if obj.Annotations["service.beta.kubernetes.io/aws-load-balancer-internal"] != "true" {
+		t.Errorf("Expected load balancer annotation, got %v", obj.Annotations)
+	}
+
+	mg.Spec.Service = &metagraf.Service{Headless: true, PublishNotReadyAddresses: true}
+	obj = genService(&mg)
+	if obj.Spec.ClusterIP != corev1.ClusterIPNone || !obj.Spec.PublishNotReadyAddresses {
+		t.Errorf("Expected headless Service publishing not ready addresses, got %v, %v", obj.Spec.ClusterIP, obj.Spec.PublishNotReadyAddresses)
+	}
+}
+
+func TestKeepNodePorts(t *testing.T) {
+	existing := &corev1.Service{}
+	existing.Spec.Ports = []corev1.ServicePort{{Name: "http", NodePort: 31000}, {Name: "https", NodePort: 31443}}
+
+	obj := corev1.Service{}
+	obj.Spec.Type = corev1.ServiceTypeNodePort
+	obj.Spec.Ports = []corev1.ServicePort{{Name: "http"}, {Name: "https", NodePort: 30443}}
+	keepNodePorts(&obj, existing)
+	if obj.Spec.Ports[0].NodePort != 31000 || obj.Spec.Ports[1].NodePort != 30443 {
+		t.Errorf("Unexpected node ports %v", obj.Spec.Ports)
+	}
+}
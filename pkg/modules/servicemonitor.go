@@ -20,7 +20,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/laetho/metagraf/internal/pkg/k8sclient"
@@ -31,8 +33,18 @@ import (
 	log "k8s.io/klog"
 )
 
+// Generates a Service and a ServiceMonitor scraping it.
 func GenServiceMonitorAndService(mg *metagraf.MetaGraf) {
+	GenService(mg)
 
+	// GenService already generated the ServiceMonitor.
+	if params.ServiceMonitor {
+		return
+	}
+	if Output && Format == "yaml" {
+		fmt.Println("---")
+	}
+	GenServiceMonitor(mg)
 }
 
 func GenServiceMonitor(mg *metagraf.MetaGraf) {
@@ -48,15 +60,16 @@ func GenServiceMonitor(mg *metagraf.MetaGraf) {
 	s["app"] = objname
 
 	eps := []monitoringv1.Endpoint{}
-	ep := monitoringv1.Endpoint{
-		TargetPort: &intstr.IntOrString{
-			IntVal: FindServiceMonitorPort(mg),
-		},
-		Path:     FindServiceMonitorPath(mg),
-		Scheme:   params.ServiceMonitorScheme,
-		Interval: params.ServiceMonitorInterval,
+	for _, port := range FindServiceMonitorPorts(mg) {
+		eps = append(eps, monitoringv1.Endpoint{
+			TargetPort: &intstr.IntOrString{
+				IntVal: port,
+			},
+			Path:     FindServiceMonitorPath(mg),
+			Scheme:   params.ServiceMonitorScheme,
+			Interval: params.ServiceMonitorInterval,
+		})
 	}
-	eps = append(eps, ep)
 
 	var obj = monitoringv1.ServiceMonitor{
 		TypeMeta: metav1.TypeMeta{
@@ -69,6 +82,7 @@ func GenServiceMonitor(mg *metagraf.MetaGraf) {
 			Labels:    l,
 		},
 		Spec: monitoringv1.ServiceMonitorSpec{
+			JobLabel:  "app",
 			Endpoints: eps,
 			Selector: metav1.LabelSelector{
 				MatchLabels: s,
@@ -120,6 +134,53 @@ func FindServiceMonitorPort(mg *metagraf.MetaGraf) int32 {
 	return params.ServiceMonitorPortDefault
 }
 
+// Returns the ports to scrape when generating ServiceMonitor or PodMonitor
+// resources. The port in the servicemonitor.monitoring.coreos.com/port
+// annotation is included, and ports looked up in .spec.ports by the names
+// listed in the servicemonitor.monitoring.coreos.com/ports annotation. Only
+// without the port annotation a port named "metrics" is included. Falls back to
+// FindServiceMonitorPort() when no ports are found or a port is explicitly
+// provided on the command line.
+func FindServiceMonitorPorts(mg *metagraf.MetaGraf) []int32 {
+	if params.ServiceMonitorPort > 1024 && params.ServiceMonitorPort != params.ServiceMonitorPortDefault {
+		return []int32{params.ServiceMonitorPort}
+	}
+
+	found := make(map[int32]bool)
+	var ports []int32
+	var names []string
+	if _, ok := mg.Metadata.Annotations["servicemonitor.monitoring.coreos.com/port"]; ok {
+		port := FindServiceMonitorPort(mg)
+		found[port] = true
+		ports = append(ports, port)
+	} else {
+		names = append(names, "metrics")
+	}
+	if v, ok := mg.Metadata.Annotations["servicemonitor.monitoring.coreos.com/ports"]; ok {
+		for _, n := range strings.Split(v, ",") {
+			names = append(names, strings.TrimSpace(n))
+		}
+	}
+
+	for _, n := range names {
+		port, ok := mg.Spec.Ports[n]
+		if !ok {
+			continue
+		}
+		if found[port] {
+			continue
+		}
+		found[port] = true
+		ports = append(ports, port)
+	}
+
+	if len(ports) == 0 {
+		return []int32{FindServiceMonitorPort(mg)}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports
+}
+
 func StoreServiceMonitor(obj monitoringv1.ServiceMonitor) {
 	client := k8sclient.GetMonitoringV1Client().ServiceMonitors(NameSpace)
 	res, _ := client.Get(context.TODO(), obj.Name, metav1.GetOptions{})